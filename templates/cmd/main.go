@@ -7,14 +7,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
 	db "github.com/a1ostudio/nova/db/sqlc"
 	_ "github.com/a1ostudio/nova/docs"
+	"github.com/a1ostudio/nova/internal/asyncq"
 	"github.com/a1ostudio/nova/internal/config"
 	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/buildinfo"
+	"github.com/a1ostudio/nova/internal/pkg/metrics"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
+	"github.com/a1ostudio/nova/internal/pkg/tracing"
 	"github.com/a1ostudio/nova/internal/server"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -38,20 +42,35 @@ var interruptSignals = []os.Signal{
 
 func main() {
 	config := mustLoadConfig()
-	logger.NewLogger(config.Env)
+	logger.NewLogger(config)
 	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
 	defer stop()
 
+	shutdownTracing := mustSetupTracing(ctx, config)
+	closeReporter := mustSetupErrorReporter(config)
+
 	connPool := mustConnectDB(ctx, config.DBSource)
 	runDBMigration(config.MigrationURL, config.DBSource)
 
 	redisClient := newRedisClient(config)
+	metrics.RegisterDBPool(connPool)
+	metrics.RegisterRedisPool(redisClient)
+
 	store := db.NewStore(connPool)
 
 	server := mustNewServer(config, store, redisClient)
 	startHTTPServer(server)
 
-	waitForShutdown(ctx, server)
+	taskProcessor := newTaskProcessor(config, redisClient)
+	startTaskProcessor(taskProcessor)
+
+	outboxRelay := asyncq.NewOutboxRelay(store, asyncq.NewRedisTaskDistributor(redisClient), redisClient, asyncq.RelayOptions{})
+	outboxRelay.Start()
+
+	<-ctx.Done()
+	// 恢复默认的信号处理，关闭过程中再次收到信号时直接退出
+	stop()
+	shutdown(config, server, taskProcessor, outboxRelay, connPool, redisClient, shutdownTracing, closeReporter)
 }
 
 func mustLoadConfig() config.Config {
@@ -62,8 +81,45 @@ func mustLoadConfig() config.Config {
 	return config
 }
 
+func mustSetupTracing(ctx context.Context, config config.Config) func(ctx context.Context) error {
+	shutdown, err := tracing.Setup(ctx, tracing.Options{
+		ServiceName: "nova",
+		Environment: config.Env,
+		Endpoint:    config.OTLPEndpoint,
+		SampleRatio: &config.TraceSampleRatio,
+	})
+	if err != nil {
+		logger.L().Fatal("cannot setup tracing", zap.Error(err))
+	}
+	return shutdown
+}
+
+// mustSetupErrorReporter 配置了 SENTRY_DSN 时将 panic 上报到 Sentry
+func mustSetupErrorReporter(config config.Config) func(ctx context.Context) error {
+	if config.SentryDSN == "" {
+		return func(context.Context) error { return nil }
+	}
+
+	reporter, err := recovery.NewSentryReporter(recovery.SentryOptions{
+		DSN:         config.SentryDSN,
+		Environment: config.Env,
+		Release:     buildinfo.Version,
+	})
+	if err != nil {
+		logger.L().Fatal("cannot create sentry reporter", zap.Error(err))
+	}
+	recovery.SetReporter(reporter)
+	return reporter.Close
+}
+
 func mustConnectDB(ctx context.Context, dbSource string) *pgxpool.Pool {
-	connPool, err := pgxpool.New(ctx, dbSource)
+	poolConfig, err := pgxpool.ParseConfig(dbSource)
+	if err != nil {
+		logger.L().Fatal("cannot parse db source", zap.Error(err))
+	}
+	poolConfig.ConnConfig.Tracer = tracing.PgxTracer{}
+
+	connPool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		logger.L().Fatal("cannot connect to db", zap.Error(err))
 	}
@@ -72,10 +128,12 @@ func mustConnectDB(ctx context.Context, dbSource string) *pgxpool.Pool {
 
 func newRedisClient(config config.Config) *redis.Client {
 	redisAddr := fmt.Sprintf("0.0.0.0:%d", config.RedisPort)
-	return redis.NewClient(&redis.Options{
+	redisClient := redis.NewClient(&redis.Options{
 		Addr:     redisAddr,
 		Password: config.RedisPassword,
 	})
+	redisClient.AddHook(tracing.RedisHook{})
+	return redisClient
 }
 
 func mustNewServer(config config.Config, store db.Store, redisClient *redis.Client) *server.Server {
@@ -94,23 +152,157 @@ func startHTTPServer(server *server.Server) {
 	}()
 }
 
-func waitForShutdown(ctx context.Context, server *server.Server) {
-	<-ctx.Done()
+func newTaskProcessor(config config.Config, redisClient *redis.Client) asyncq.TaskProcessor {
+	taskProcessor := asyncq.NewRedisTaskProcessor(redisClient, asyncq.ProcessorOptions{
+		Concurrency: config.WorkerConcurrency,
+		// 留出时间让超时的任务放回队列，再结束 workers 阶段
+		ShutdownTimeout: config.ShutdownWorkerTimeout * 4 / 5,
+	})
+	// 在这里注册任务处理函数，例如：
+	// taskProcessor.Handle(service.TaskSendEmail, asyncq.HandlerOf(emailService.HandleSendEmail))
+	return taskProcessor
+}
+
+func startTaskProcessor(taskProcessor asyncq.TaskProcessor) {
+	if err := taskProcessor.Start(); err != nil {
+		logger.L().Fatal("task processor failed", zap.Error(err))
+	}
+}
+
+// shutdownPhase 优雅关闭的一个阶段，每个阶段有独立的超时时间
+type shutdownPhase struct {
+	name    string
+	timeout time.Duration
+	fn      func(ctx context.Context) error
+	// 之前的阶段超时仍在后台运行时跳过，避免关闭它们正在使用的连接
+	skipIfPending bool
+}
+
+// shutdown 按顺序执行关闭阶段：
+//  1. 就绪检查返回失败，等待负载均衡摘除流量
+//  2. 停止接受新连接，等待处理中的 HTTP 请求完成
+//  3. 停止 outbox 投递，再等待处理中的后台任务完成
+//  4. 关闭数据库连接池和 Redis
+//  5. 导出剩余的 trace 和待上报的 panic
+//
+// 某个阶段失败或超时后继续执行后续阶段，但之前的阶段仍在运行时不关闭连接，最后刷新日志
+func shutdown(
+	config config.Config,
+	server *server.Server,
+	taskProcessor asyncq.TaskProcessor,
+	outboxRelay *asyncq.OutboxRelay,
+	connPool *pgxpool.Pool,
+	redisClient *redis.Client,
+	shutdownTracing func(ctx context.Context) error,
+	closeReporter func(ctx context.Context) error,
+) {
 	logger.L().Info("Shutting down gracefully...")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	start := time.Now()
+
+	phases := []shutdownPhase{
+		{
+			name:    "pre_stop",
+			timeout: config.ShutdownPreStopDelay + time.Second,
+			fn: func(ctx context.Context) error {
+				server.MarkUnready()
+				select {
+				case <-time.After(config.ShutdownPreStopDelay):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		},
+		{
+			name:    "http",
+			timeout: config.ShutdownHTTPTimeout,
+			fn:      server.Shutdown,
+		},
+		{
+			name:    "workers",
+			timeout: config.ShutdownWorkerTimeout,
+			fn: func(ctx context.Context) error {
+				// outbox 会向任务队列投递任务，先停止投递，再等待处理中的任务完成
+				if err := outboxRelay.Shutdown(ctx); err != nil {
+					return fmt.Errorf("shutdown outbox relay: %w", err)
+				}
+				return taskProcessor.Shutdown(ctx)
+			},
+		},
+		{
+			name:          "close",
+			timeout:       config.ShutdownCloseTimeout,
+			skipIfPending: true,
+			fn: func(ctx context.Context) error {
+				err := redisClient.Close()
+				// Close 会等待所有连接归还，不响应 ctx，超时由 runShutdownPhase 控制
+				connPool.Close()
+				return err
+			},
+		},
+		{
+			name:    "telemetry",
+			timeout: config.ShutdownCloseTimeout,
+			fn: func(ctx context.Context) error {
+				return errors.Join(shutdownTracing(ctx), closeReporter(ctx))
+			},
+		},
+	}
+
+	pending := false
+	for _, phase := range phases {
+		if phase.skipIfPending && pending {
+			logger.L().Warn("shutdown phase skipped, earlier phases are still running", zap.String("phase", phase.name))
+			continue
+		}
+		if !runShutdownPhase(phase) {
+			pending = true
+		}
+	}
+
+	logger.L().Info("Application stopped", zap.Duration("elapsed", time.Since(start)))
+	logger.Close()
+}
+
+// runShutdownPhase 执行一个关闭阶段，阶段超时后仍在后台运行时返回 false
+func runShutdownPhase(phase shutdownPhase) bool {
+	logger.L().Info("shutdown phase started",
+		zap.String("phase", phase.name),
+		zap.Duration("timeout", phase.timeout),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), phase.timeout)
 	defer cancel()
-	var wg sync.WaitGroup
-	wg.Add(1)
+
+	start := time.Now()
+	errCh := make(chan error, 1)
 	go func() {
-		defer wg.Done()
-		if err := server.Shutdown(shutdownCtx); err != nil {
-			logger.L().Error("HTTP server shutdown failed", zap.Error(err))
-		} else {
-			logger.L().Info("HTTP server stopped gracefully")
-		}
+		errCh <- phase.fn(ctx)
 	}()
-	wg.Wait()
-	logger.L().Info("Application stopped")
+
+	fields := []zap.Field{
+		zap.String("phase", phase.name),
+	}
+	select {
+	case err := <-errCh:
+		fields = append(fields, zap.Duration("elapsed", time.Since(start)))
+		// fn 因超时返回时，它启动的关闭过程可能仍在后台运行
+		if errors.Is(err, context.DeadlineExceeded) {
+			logger.L().Error("shutdown phase timed out", append(fields, zap.Error(err))...)
+			return false
+		}
+		if err != nil {
+			logger.L().Error("shutdown phase failed", append(fields, zap.Error(err))...)
+			return true
+		}
+		logger.L().Info("shutdown phase completed", fields...)
+		return true
+	case <-ctx.Done():
+		logger.L().Error("shutdown phase timed out, still running in background",
+			append(fields, zap.Duration("elapsed", time.Since(start)))...,
+		)
+		return false
+	}
 }
 
 func runDBMigration(migrationURL, dbSource string) {
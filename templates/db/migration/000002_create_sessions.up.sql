@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id uuid PRIMARY KEY,
+    family_id uuid NOT NULL,
+    user_id bigint NOT NULL,
+    refresh_token_hash varchar NOT NULL,
+    user_agent varchar NOT NULL DEFAULT '',
+    client_ip varchar NOT NULL DEFAULT '',
+    is_blocked boolean NOT NULL DEFAULT false,
+    replaced_by uuid,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sessions_family_id_idx ON sessions (family_id);
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
@@ -0,0 +1,6 @@
+-- 记录每个受保护资源最后一次写入时使用的 fencing token
+CREATE TABLE IF NOT EXISTS lock_fences (
+    resource varchar PRIMARY KEY,
+    token bigint NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,15 @@
+-- 事务性发件箱：业务事务中写入，提交后由 relay 投递到任务队列或 pub/sub
+CREATE TABLE IF NOT EXISTS outbox (
+    id bigserial PRIMARY KEY,
+    kind varchar NOT NULL,
+    topic varchar NOT NULL,
+    queue varchar NOT NULL DEFAULT '',
+    payload jsonb NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error varchar NOT NULL DEFAULT '',
+    available_at timestamptz NOT NULL DEFAULT now(),
+    published_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (available_at, id) WHERE published_at IS NULL;
@@ -0,0 +1,5 @@
+-- 超过最大尝试次数的消息标记为 dead，不再投递，需要人工处理
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS dead_at timestamptz;
+
+DROP INDEX IF EXISTS outbox_unpublished_idx;
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (available_at, id) WHERE published_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,11 @@
+-- name: AdvanceFencingToken :execrows
+INSERT INTO lock_fences (
+    resource,
+    token
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (resource) DO UPDATE
+SET token = EXCLUDED.token,
+    updated_at = now()
+WHERE lock_fences.token <= EXCLUDED.token;
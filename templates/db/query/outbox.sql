@@ -0,0 +1,46 @@
+-- name: CreateOutboxMessage :one
+INSERT INTO outbox (
+    kind,
+    topic,
+    queue,
+    payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ClaimOutboxMessages :many
+-- 认领到期的消息并将 available_at 推迟到 locked_until 作为租约，relay 在租约内未处理完时其他实例可以重新认领
+UPDATE outbox
+SET attempts = attempts + 1,
+    available_at = sqlc.arg(locked_until)
+WHERE id IN (
+    SELECT id FROM outbox
+    WHERE published_at IS NULL
+      AND dead_at IS NULL
+      AND available_at <= now()
+    ORDER BY id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxMessagesPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: MarkOutboxMessageFailed :exec
+UPDATE outbox
+SET last_error = $2,
+    available_at = $3
+WHERE id = $1;
+
+-- name: MarkOutboxMessageDead :exec
+UPDATE outbox
+SET last_error = $2,
+    dead_at = now()
+WHERE id = $1;
+
+-- name: DeletePublishedOutboxMessages :execrows
+DELETE FROM outbox
+WHERE published_at < sqlc.arg(published_before)::timestamptz;
@@ -0,0 +1,28 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+    id,
+    family_id,
+    user_id,
+    refresh_token_hash,
+    user_agent,
+    client_ip,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: RotateSession :execrows
+UPDATE sessions
+SET replaced_by = sqlc.arg(replaced_by)
+WHERE id = sqlc.arg(id)
+  AND replaced_by IS NULL
+  AND is_blocked = false;
+
+-- name: BlockSessionFamily :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1;
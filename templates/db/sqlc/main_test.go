@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testStore 连接 TEST_DB_SOURCE 指定的数据库，未设置时需要数据库的测试会跳过
+var testStore Store
+
+func TestMain(m *testing.M) {
+	source := os.Getenv("TEST_DB_SOURCE")
+	if source == "" {
+		os.Exit(m.Run())
+	}
+
+	migration, err := migrate.New("file://../migration", source)
+	if err != nil {
+		log.Fatal("cannot create migration: ", err)
+	}
+	if err := migration.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		log.Fatal("cannot migrate test db: ", err)
+	}
+
+	connPool, err := pgxpool.New(context.Background(), source)
+	if err != nil {
+		log.Fatal("cannot connect to test db: ", err)
+	}
+	testStore = NewStore(connPool)
+
+	code := m.Run()
+	connPool.Close()
+	os.Exit(code)
+}
+
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+	if testStore == nil {
+		t.Skip("TEST_DB_SOURCE is not set")
+	}
+	return testStore
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	OutboxKindTask  = "task"  // 投递到后台任务队列，topic 为任务类型
+	OutboxKindEvent = "event" // 追加到 Redis Stream，topic 为 stream 名
+)
+
+// AddOutboxTask 在当前事务中登记后台任务，事务提交后由 relay 投递，回滚则不会投递。
+// queue 为空时使用默认队列
+func (q *Queries) AddOutboxTask(ctx context.Context, taskType, queue string, payload any) error {
+	return q.addOutboxMessage(ctx, OutboxKindTask, taskType, queue, payload)
+}
+
+// AddOutboxEvent 在当前事务中登记事件，事务提交后由 relay 追加到 Redis Stream，
+// 消费方通过消费者组读取并 ACK，可能收到重复的事件，需要按 outbox_id 去重
+func (q *Queries) AddOutboxEvent(ctx context.Context, stream string, payload any) error {
+	return q.addOutboxMessage(ctx, OutboxKindEvent, stream, "", payload)
+}
+
+func (q *Queries) addOutboxMessage(ctx context.Context, kind, topic, queue string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal outbox %s payload: %w", topic, err)
+	}
+
+	_, err = q.CreateOutboxMessage(ctx, CreateOutboxMessageParams{
+		Kind:    kind,
+		Topic:   topic,
+		Queue:   queue,
+		Payload: data,
+	})
+	return err
+}
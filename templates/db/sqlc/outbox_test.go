@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// drainOutbox 将其他测试遗留的待投递消息标记为已投递
+func drainOutbox(t *testing.T, store Store) {
+	messages, err := store.ClaimOutboxMessages(context.Background(), ClaimOutboxMessagesParams{
+		LockedUntil: time.Now().Add(time.Hour),
+		BatchSize:   1000,
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.MarkOutboxMessagesPublished(context.Background(), messageIDs(messages)))
+}
+
+func createTestOutboxMessage(t *testing.T, store Store) Outbox {
+	message, err := store.CreateOutboxMessage(context.Background(), CreateOutboxMessageParams{
+		Kind:    OutboxKindTask,
+		Topic:   "test:outbox",
+		Payload: []byte(`{}`),
+	})
+	require.NoError(t, err)
+	return message
+}
+
+func messageIDs(messages []Outbox) []int64 {
+	ids := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestClaimOutboxMessagesSkipLocked(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	drainOutbox(t, store)
+
+	first := createTestOutboxMessage(t, store)
+	second := createTestOutboxMessage(t, store)
+	lockedUntil := time.Now().Add(time.Hour)
+
+	err := store.ExecTx(ctx, func(q *Queries) error {
+		claimed, err := q.ClaimOutboxMessages(ctx, ClaimOutboxMessagesParams{LockedUntil: lockedUntil, BatchSize: 1})
+		require.NoError(t, err)
+		require.Equal(t, []int64{first.ID}, messageIDs(claimed))
+
+		// 另一个连接跳过被锁定的行，不会阻塞
+		claimed, err = store.ClaimOutboxMessages(ctx, ClaimOutboxMessagesParams{LockedUntil: lockedUntil, BatchSize: 10})
+		require.NoError(t, err)
+		require.Equal(t, []int64{second.ID}, messageIDs(claimed))
+		require.Equal(t, int32(1), claimed[0].Attempts)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// 租约期间不会被重复认领
+	claimed, err := store.ClaimOutboxMessages(ctx, ClaimOutboxMessagesParams{LockedUntil: lockedUntil, BatchSize: 10})
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+
+	require.NoError(t, store.MarkOutboxMessagesPublished(ctx, []int64{first.ID, second.ID}))
+}
+
+func TestClaimOutboxMessagesLease(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	drainOutbox(t, store)
+
+	message := createTestOutboxMessage(t, store)
+
+	// 租约已过期的消息可以被重新认领，并计入尝试次数
+	_, err := store.ClaimOutboxMessages(ctx, ClaimOutboxMessagesParams{LockedUntil: time.Now(), BatchSize: 10})
+	require.NoError(t, err)
+	claimed, err := store.ClaimOutboxMessages(ctx, ClaimOutboxMessagesParams{LockedUntil: time.Now(), BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, []int64{message.ID}, messageIDs(claimed))
+	require.Equal(t, int32(2), claimed[0].Attempts)
+
+	// dead 的消息不再被认领
+	require.NoError(t, store.MarkOutboxMessageDead(ctx, MarkOutboxMessageDeadParams{ID: message.ID, LastError: "boom"}))
+	claimed, err = store.ClaimOutboxMessages(ctx, ClaimOutboxMessagesParams{LockedUntil: time.Now(), BatchSize: 10})
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+}
@@ -4,7 +4,26 @@
 
 package db
 
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type Querier interface {
+	AdvanceFencingToken(ctx context.Context, arg AdvanceFencingTokenParams) (int64, error)
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) error
+	// 认领到期的消息并将 available_at 推迟到 locked_until 作为租约，relay 在租约内未处理完时其他实例可以重新认领
+	ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]Outbox, error)
+	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) (Outbox, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	DeletePublishedOutboxMessages(ctx context.Context, publishedBefore time.Time) (int64, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	MarkOutboxMessageDead(ctx context.Context, arg MarkOutboxMessageDeadParams) error
+	MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMessageFailedParams) error
+	MarkOutboxMessagesPublished(ctx context.Context, ids []int64) error
+	RotateSession(ctx context.Context, arg RotateSessionParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
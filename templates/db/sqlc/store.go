@@ -2,14 +2,21 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrStaleFencingToken 表示写入方持有的锁已被他人接管
+var ErrStaleFencingToken = errors.New("stale fencing token")
+
 type Store interface {
 	Querier
 	ExecTx(ctx context.Context, fn func(q *Queries) error) error
+	ExecFencedTx(ctx context.Context, resource string, token int64, fn func(q *Queries) error) error
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error)
+	Ping(ctx context.Context) error
 }
 
 type SQLStore struct {
@@ -24,6 +31,11 @@ func NewStore(connPoll *pgxpool.Pool) Store {
 	}
 }
 
+// Ping 检查数据库连接是否可用
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.connPoll.Ping(ctx)
+}
+
 func (store *SQLStore) ExecTx(ctx context.Context, fn func(q *Queries) error) error {
 	tx, err := store.connPoll.Begin(ctx)
 	if err != nil {
@@ -48,3 +60,22 @@ func (store *SQLStore) ExecTx(ctx context.Context, fn func(q *Queries) error) er
 
 	return tx.Commit(ctx)
 }
+
+// ExecFencedTx 在事务中先推进 resource 的 fencing token，再执行 fn。
+// token 小于已记录的值时返回 ErrStaleFencingToken，fn 不会执行。
+// 推进 token 时持有的行锁会保持到事务结束，因此同一 resource 的写入是串行的
+func (store *SQLStore) ExecFencedTx(ctx context.Context, resource string, token int64, fn func(q *Queries) error) error {
+	return store.ExecTx(ctx, func(q *Queries) error {
+		rows, err := q.AdvanceFencingToken(ctx, AdvanceFencingTokenParams{
+			Resource: resource,
+			Token:    token,
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrStaleFencingToken
+		}
+		return fn(q)
+	})
+}
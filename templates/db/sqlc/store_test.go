@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecFencedTx(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	resource := "test:" + uuid.NewString()
+
+	var executed []int64
+	fencedWrite := func(token int64) error {
+		return store.ExecFencedTx(ctx, resource, token, func(q *Queries) error {
+			executed = append(executed, token)
+			return nil
+		})
+	}
+
+	require.NoError(t, fencedWrite(1))
+	require.NoError(t, fencedWrite(3))
+	// 同一个持有者重复写入
+	require.NoError(t, fencedWrite(3))
+
+	// 锁被 token 3 接管后，token 2 的持有者不能再写入，fn 不会执行
+	require.ErrorIs(t, fencedWrite(2), ErrStaleFencingToken)
+	require.Equal(t, []int64{1, 3, 3}, executed)
+}
+
+func TestExecFencedTxRollback(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	resource := "test:" + uuid.NewString()
+
+	// fn 失败时推进的 token 随事务回滚，较小的 token 仍然可以写入
+	errTest := errors.New("test error")
+	err := store.ExecFencedTx(ctx, resource, 5, func(q *Queries) error {
+		return errTest
+	})
+	require.ErrorIs(t, err, errTest)
+
+	require.NoError(t, store.ExecFencedTx(ctx, resource, 4, func(q *Queries) error {
+		return nil
+	}))
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrSessionAlreadyRotated 表示会话在事务中已被并发轮换或阻止
+var ErrSessionAlreadyRotated = errors.New("session already rotated")
+
+type RotateSessionTxParams struct {
+	SessionID  uuid.UUID           // 被轮换的会话
+	NewSession CreateSessionParams // 轮换后的新会话，与被轮换的会话属于同一个会话族
+}
+
+// RotateSessionTx 在同一个事务中创建新会话并将旧会话标记为已被替换。
+// 旧会话已被替换或阻止时返回 ErrSessionAlreadyRotated，新会话不会保存
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error) {
+	var session Session
+	err := store.ExecTx(ctx, func(q *Queries) error {
+		var err error
+		session, err = q.CreateSession(ctx, arg.NewSession)
+		if err != nil {
+			return err
+		}
+
+		rows, err := q.RotateSession(ctx, RotateSessionParams{
+			ID:         arg.SessionID,
+			ReplacedBy: pgtype.UUID{Bytes: session.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrSessionAlreadyRotated
+		}
+		return nil
+	})
+	return session, err
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newSessionParams(familyID uuid.UUID) CreateSessionParams {
+	return CreateSessionParams{
+		ID:               uuid.New(),
+		FamilyID:         familyID,
+		UserID:           1,
+		RefreshTokenHash: uuid.NewString(),
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	session, err := store.CreateSession(ctx, newSessionParams(uuid.New()))
+	require.NoError(t, err)
+
+	renewed, err := store.RotateSessionTx(ctx, RotateSessionTxParams{
+		SessionID:  session.ID,
+		NewSession: newSessionParams(session.FamilyID),
+	})
+	require.NoError(t, err)
+	require.Equal(t, session.FamilyID, renewed.FamilyID)
+
+	rotated, err := store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.ReplacedBy.Valid)
+	require.Equal(t, renewed.ID, uuid.UUID(rotated.ReplacedBy.Bytes))
+
+	// 已被轮换的会话不能再次轮换，新会话随事务回滚
+	arg := newSessionParams(session.FamilyID)
+	_, err = store.RotateSessionTx(ctx, RotateSessionTxParams{SessionID: session.ID, NewSession: arg})
+	require.ErrorIs(t, err, ErrSessionAlreadyRotated)
+	_, err = store.GetSession(ctx, arg.ID)
+	require.Error(t, err)
+}
+
+func TestRotateSessionTxConcurrent(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	session, err := store.CreateSession(ctx, newSessionParams(uuid.New()))
+	require.NoError(t, err)
+
+	const n = 5
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.RotateSessionTx(ctx, RotateSessionTxParams{
+				SessionID:  session.ID,
+				NewSession: newSessionParams(session.FamilyID),
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrSessionAlreadyRotated)
+	}
+	require.Equal(t, 1, succeeded)
+}
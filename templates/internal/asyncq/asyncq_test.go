@@ -0,0 +1,297 @@
+package asyncq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/config"
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
+	"github.com/a1ostudio/nova/internal/pkg/tracing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testPayload struct {
+	UserID int64 `json:"user_id"`
+}
+
+func TestMain(m *testing.M) {
+	logger.NewLogger(config.Config{Env: config.Dev})
+
+	os.Exit(m.Run())
+}
+
+func newTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb, mr
+}
+
+func newTestProcessor(t *testing.T, rdb *redis.Client) TaskProcessor {
+	processor := NewRedisTaskProcessor(rdb, ProcessorOptions{
+		Concurrency:  2,
+		PollInterval: 10 * time.Millisecond,
+		RetryDelay: func(int, error, *asynq.Task) time.Duration {
+			return 0
+		},
+		ShutdownTimeout: time.Second,
+	})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = processor.Shutdown(ctx)
+	})
+	return processor
+}
+
+func newTestInspector(t *testing.T, rdb *redis.Client) *asynq.Inspector {
+	inspector := asynq.NewInspectorFromRedisClient(rdb)
+	t.Cleanup(func() { _ = inspector.Close() })
+	return inspector
+}
+
+// archivedTasks 返回队列中已归档的任务，队列不存在时返回空
+func archivedTasks(inspector *asynq.Inspector, queue string) []*asynq.TaskInfo {
+	tasks, _ := inspector.ListArchivedTasks(queue)
+	return tasks
+}
+
+func TestProcessTask(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	processor := newTestProcessor(t, rdb)
+
+	received := make(chan testPayload, 1)
+	processor.Handle("user:welcome", HandlerOf(func(ctx context.Context, payload testPayload) error {
+		queue, ok := asynq.GetQueueName(ctx)
+		require.True(t, ok)
+		require.Equal(t, QueueCritical, queue)
+		received <- payload
+		return nil
+	}))
+	require.NoError(t, processor.Start())
+
+	info, err := distributor.DistributeTask(context.Background(), "user:welcome", testPayload{UserID: 42}, asynq.Queue(QueueCritical))
+	require.NoError(t, err)
+	require.NotEmpty(t, info.ID)
+	require.Equal(t, QueueCritical, info.Queue)
+
+	select {
+	case payload := <-received:
+		require.Equal(t, int64(42), payload.UserID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not processed")
+	}
+}
+
+func TestRetryAndArchive(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	processor := newTestProcessor(t, rdb)
+	inspector := newTestInspector(t, rdb)
+
+	var attempts atomic.Int32
+	processor.Handle("user:fail", asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		attempts.Add(1)
+		return errors.New("boom")
+	}))
+	require.NoError(t, processor.Start())
+
+	_, err := distributor.DistributeTask(context.Background(), "user:fail", testPayload{UserID: 1}, asynq.MaxRetry(2))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(archivedTasks(inspector, QueueDefault)) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, int32(3), attempts.Load())
+
+	archived := archivedTasks(inspector, QueueDefault)[0]
+	require.Equal(t, 2, archived.Retried)
+	require.Equal(t, "boom", archived.LastErr)
+}
+
+func TestSkipRetry(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	processor := newTestProcessor(t, rdb)
+	inspector := newTestInspector(t, rdb)
+
+	var attempts atomic.Int32
+	processor.Handle("user:welcome", HandlerOf(func(ctx context.Context, payload testPayload) error {
+		attempts.Add(1)
+		return nil
+	}))
+	require.NoError(t, processor.Start())
+
+	// payload 无法解码时直接归档，不调用处理函数
+	_, err := distributor.DistributeTask(context.Background(), "user:welcome", "not an object")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(archivedTasks(inspector, QueueDefault)) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+	require.Zero(t, attempts.Load())
+	require.Zero(t, archivedTasks(inspector, QueueDefault)[0].Retried)
+}
+
+func TestHandlerPanic(t *testing.T) {
+	reporter := recovery.NewMemoryReporter()
+	recovery.SetReporter(reporter)
+	t.Cleanup(func() { recovery.SetReporter(nil) })
+
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	processor := newTestProcessor(t, rdb)
+	inspector := newTestInspector(t, rdb)
+	processor.Handle("user:panic", asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		panic("boom")
+	}))
+	require.NoError(t, processor.Start())
+
+	info, err := distributor.DistributeTask(context.Background(), "user:panic", testPayload{}, asynq.MaxRetry(0))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(archivedTasks(inspector, QueueDefault)) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	events := reporter.Events()
+	require.Len(t, events, 1)
+	require.Equal(t, recovery.SourceTask, events[0].Source)
+	require.Equal(t, "user:panic", events[0].Tags["task_type"])
+	require.Equal(t, info.ID, events[0].Tags["task_id"])
+}
+
+func TestProcessIn(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	inspector := newTestInspector(t, rdb)
+
+	info, err := distributor.DistributeTask(context.Background(), "user:welcome", testPayload{UserID: 1}, asynq.ProcessIn(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, asynq.TaskStateScheduled, info.State)
+
+	queue, err := inspector.GetQueueInfo(QueueDefault)
+	require.NoError(t, err)
+	require.Equal(t, 1, queue.Scheduled)
+	require.Zero(t, queue.Pending)
+}
+
+func TestDistributeDuplicateTaskID(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+
+	_, err := distributor.DistributeTask(context.Background(), "user:welcome", testPayload{}, asynq.TaskID("welcome:1"))
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(context.Background(), "user:welcome", testPayload{}, asynq.TaskID("welcome:1"))
+	require.ErrorIs(t, err, asynq.ErrTaskIDConflict)
+}
+
+func TestShutdownWaitsForTasks(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	processor := newTestProcessor(t, rdb)
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	processor.Handle("user:slow", asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	}))
+	require.NoError(t, processor.Start())
+
+	_, err := distributor.DistributeTask(context.Background(), "user:slow", testPayload{})
+	require.NoError(t, err)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, processor.Shutdown(ctx))
+	require.True(t, finished.Load())
+}
+
+func TestHealthCheck(t *testing.T) {
+	rdb, mr := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	check := HealthCheck(rdb, map[string]int{QueueDefault: 1}, 1)
+
+	require.NoError(t, check(context.Background()))
+
+	for range 2 {
+		_, err := distributor.DistributeTask(context.Background(), "user:welcome", testPayload{})
+		require.NoError(t, err)
+	}
+	require.Error(t, check(context.Background()))
+
+	mr.Close()
+	require.Error(t, HealthCheck(rdb, nil, 0)(context.Background()))
+}
+
+func TestTracePropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{ServiceName: "test", Exporter: exporter})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	processor := newTestProcessor(t, rdb)
+
+	received := make(chan trace.SpanContext, 1)
+	processor.Handle("user:welcome", asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		require.Contains(t, task.Headers(), "traceparent")
+		received <- trace.SpanContextFromContext(ctx)
+		return nil
+	}))
+	require.NoError(t, processor.Start())
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+	_, err = distributor.DistributeTask(ctx, "user:welcome", testPayload{UserID: 1})
+	require.NoError(t, err)
+	parent.End()
+
+	select {
+	case spanCtx := <-received:
+		require.Equal(t, parent.SpanContext().TraceID(), spanCtx.TraceID())
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not processed")
+	}
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	distributor := NewRedisTaskDistributor(rdb)
+	processor := newTestProcessor(t, rdb)
+
+	received := make(chan string, 1)
+	processor.Handle("user:welcome", asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		requestID, _ := logger.RequestIDFromContext(ctx)
+		received <- requestID
+		return nil
+	}))
+	require.NoError(t, processor.Start())
+
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	_, err := distributor.DistributeTask(ctx, "user:welcome", testPayload{UserID: 1})
+	require.NoError(t, err)
+
+	select {
+	case requestID := <-received:
+		require.Equal(t, "req-1", requestID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not processed")
+	}
+}
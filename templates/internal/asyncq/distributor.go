@@ -0,0 +1,75 @@
+package asyncq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/tracing"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TaskDistributor 供 service 投递后台任务
+type TaskDistributor interface {
+	// DistributeTask 将 payload 编码为 JSON 后投递，opts 为 asynq 的任务选项，如 asynq.Queue、asynq.ProcessIn
+	DistributeTask(ctx context.Context, taskType string, payload any, opts ...asynq.Option) (*asynq.TaskInfo, error)
+}
+
+type RedisTaskDistributor struct {
+	client *asynq.Client
+}
+
+// NewRedisTaskDistributor 使用已有的 Redis 连接投递任务，Redis 连接由调用方关闭
+func NewRedisTaskDistributor(rdb redis.UniversalClient) TaskDistributor {
+	return &RedisTaskDistributor{
+		client: asynq.NewClientFromRedisClient(rdb),
+	}
+}
+
+func (distributor *RedisTaskDistributor) DistributeTask(ctx context.Context, taskType string, payload any, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s payload: %w", taskType, err)
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "asyncq enqueue "+taskType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(taskAttributes(taskType, "", "")...),
+	)
+	// traceparent 随任务保存，处理任务时延续同一个 trace
+	headers := map[string]string{}
+	tracing.Inject(ctx, headers)
+	if requestID, ok := logger.RequestIDFromContext(ctx); ok {
+		headers[headerRequestID] = requestID
+	}
+
+	info, err := distributor.client.EnqueueContext(ctx, asynq.NewTaskWithHeaders(taskType, data, headers), opts...)
+	if err == nil {
+		span.SetAttributes(taskAttributes(taskType, info.Queue, info.ID)...)
+	}
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("distribute task %s: %w", taskType, err)
+	}
+	return info, nil
+}
+
+func taskAttributes(taskType, queue, taskID string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKey.String("asynq"),
+		attribute.String("asyncq.task.type", taskType),
+	}
+	if queue != "" {
+		attrs = append(attrs, semconv.MessagingDestinationName(queue))
+	}
+	if taskID != "" {
+		attrs = append(attrs, semconv.MessagingMessageID(taskID))
+	}
+	return attrs
+}
@@ -0,0 +1,46 @@
+package asyncq
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// HealthCheck 返回队列的健康检查函数，Redis 不可用或任一队列积压超过 maxPending 时返回错误，
+// maxPending <= 0 时不检查积压。队列的详细状态可以在 asynqmon 中查看
+func HealthCheck(rdb redis.UniversalClient, queues map[string]int, maxPending int) func(ctx context.Context) error {
+	if len(queues) == 0 {
+		queues = DefaultQueues
+	}
+	inspector := asynq.NewInspectorFromRedisClient(rdb)
+
+	return func(ctx context.Context) error {
+		if err := rdb.Ping(ctx).Err(); err != nil {
+			return err
+		}
+		if maxPending <= 0 {
+			return nil
+		}
+
+		// 只检查已经创建的队列，还没有投递过任务的队列不存在
+		existing, err := inspector.Queues()
+		if err != nil {
+			return fmt.Errorf("list queues: %w", err)
+		}
+		for _, queue := range existing {
+			if _, ok := queues[queue]; !ok {
+				continue
+			}
+			info, err := inspector.GetQueueInfo(queue)
+			if err != nil {
+				return fmt.Errorf("get queue info %s: %w", queue, err)
+			}
+			if info.Pending > maxPending {
+				return fmt.Errorf("queue %s has %d pending tasks, exceeds %d", queue, info.Pending, maxPending)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,252 @@
+package asyncq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	db "github.com/a1ostudio/nova/db/sqlc"
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
+	"github.com/a1ostudio/nova/internal/pkg/tracing"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type RelayOptions struct {
+	BatchSize    int           // 每批认领的消息数，默认 100
+	PollInterval time.Duration // 没有待投递消息时的轮询间隔，默认 1s
+	Retention    time.Duration // 已投递消息的保留时间，默认 7 天
+	LeaseTimeout time.Duration // 认领消息的租约，超时未处理完的消息会被重新认领，默认 1 分钟
+	MaxAttempts  int32         // 最大尝试次数，超过后标记为 dead 不再投递，默认 16
+	StreamMaxLen int64         // 事件 stream 的近似最大长度，默认 100000
+}
+
+// errLeaseExhausted 最后一次尝试的租约过期，通常是 relay 在投递过程中退出
+var errLeaseExhausted = errors.New("lease expired on the last attempt")
+
+func (opts RelayOptions) withDefaults() RelayOptions {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	if opts.LeaseTimeout <= 0 {
+		opts.LeaseTimeout = time.Minute
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 16
+	}
+	if opts.StreamMaxLen <= 0 {
+		opts.StreamMaxLen = 100000
+	}
+	return opts
+}
+
+// OutboxRelay 将已提交的 outbox 消息投递到任务队列或 Redis Stream。
+// 消息先在短事务中通过 SKIP LOCKED 认领并加上租约，投递在事务之外进行，成功后再标记为已投递；
+// 投递成功但标记失败或租约过期时消息会被再次投递，因此是至少一次投递。
+// 投递失败的消息按 DefaultRetryDelay 退避重试，超过 MaxAttempts 后标记为 dead
+type OutboxRelay struct {
+	store       db.Store
+	distributor TaskDistributor
+	rdb         *redis.Client
+	opts        RelayOptions
+
+	quit     chan struct{}
+	quitOnce sync.Once
+	done     chan struct{}
+}
+
+func NewOutboxRelay(store db.Store, distributor TaskDistributor, rdb *redis.Client, opts RelayOptions) *OutboxRelay {
+	return &OutboxRelay{
+		store:       store,
+		distributor: distributor,
+		rdb:         rdb,
+		opts:        opts.withDefaults(),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (relay *OutboxRelay) Start() {
+	relayLogger().Info("Starting outbox relay...")
+	recovery.Go(context.Background(), "asyncq.outbox", relay.run)
+}
+
+// Shutdown 停止认领新消息并等待当前批次完成
+func (relay *OutboxRelay) Shutdown(ctx context.Context) error {
+	relayLogger().Info("Shutting down outbox relay...")
+	relay.quitOnce.Do(func() {
+		close(relay.quit)
+	})
+
+	select {
+	case <-relay.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (relay *OutboxRelay) run() {
+	defer close(relay.done)
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		n, err := relay.relayBatch(context.Background())
+		if err != nil {
+			relayLogger().Error("failed to relay outbox messages", zap.Error(err))
+		}
+
+		// 一批满了说明还有积压，立即处理下一批
+		wait := relay.opts.PollInterval
+		if err == nil && n == relay.opts.BatchSize {
+			wait = 0
+		}
+
+		select {
+		case <-relay.quit:
+			return
+		case <-cleanup.C:
+			relay.cleanup(context.Background())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// relayBatch 认领并投递一批消息，返回认领的消息数
+func (relay *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	messages, err := relay.store.ClaimOutboxMessages(ctx, db.ClaimOutboxMessagesParams{
+		LockedUntil: time.Now().Add(relay.opts.LeaseTimeout),
+		BatchSize:   int32(relay.opts.BatchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("claim outbox messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	// 每一批消息一个 span，投递和日志都关联到同一个 trace
+	ctx, span := tracing.Tracer().Start(ctx, "asyncq outbox relay",
+		trace.WithAttributes(attribute.Int("asyncq.outbox.messages", len(messages))),
+	)
+
+	var errs []error
+	published := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		// 认领时已经计入本次尝试，超过上限说明之前的租约都过期了
+		publishErr := errLeaseExhausted
+		if message.Attempts <= relay.opts.MaxAttempts {
+			publishErr = relay.publish(ctx, message)
+		}
+		if publishErr == nil {
+			published = append(published, message.ID)
+			continue
+		}
+		if err := relay.markFailed(ctx, message, publishErr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(published) > 0 {
+		if err := relay.store.MarkOutboxMessagesPublished(ctx, published); err != nil {
+			errs = append(errs, fmt.Errorf("mark outbox messages published: %w", err))
+		}
+	}
+	err = errors.Join(errs...)
+	tracing.EndSpan(span, err, attribute.Int("asyncq.outbox.published", len(published)))
+	return len(messages), err
+}
+
+// markFailed 安排失败的消息退避重试，尝试次数用完时标记为 dead
+func (relay *OutboxRelay) markFailed(ctx context.Context, message db.Outbox, publishErr error) error {
+	fields := []zap.Field{
+		zap.Int64("outbox_id", message.ID),
+		zap.String("topic", message.Topic),
+		zap.Int32("attempts", message.Attempts),
+		zap.Error(publishErr),
+	}
+
+	if message.Attempts >= relay.opts.MaxAttempts {
+		logger.FromContext(ctx).Named("asyncq.outbox").Error("outbox message is dead", fields...)
+		err := relay.store.MarkOutboxMessageDead(ctx, db.MarkOutboxMessageDeadParams{
+			ID:        message.ID,
+			LastError: publishErr.Error(),
+		})
+		if err != nil {
+			return fmt.Errorf("mark outbox message %d dead: %w", message.ID, err)
+		}
+		return nil
+	}
+
+	logger.FromContext(ctx).Named("asyncq.outbox").Warn("failed to publish outbox message", fields...)
+	err := relay.store.MarkOutboxMessageFailed(ctx, db.MarkOutboxMessageFailedParams{
+		ID:          message.ID,
+		LastError:   publishErr.Error(),
+		AvailableAt: time.Now().Add(DefaultRetryDelay(int(message.Attempts)-1, publishErr, nil)),
+	})
+	if err != nil {
+		return fmt.Errorf("mark outbox message %d failed: %w", message.ID, err)
+	}
+	return nil
+}
+
+func (relay *OutboxRelay) publish(ctx context.Context, message db.Outbox) error {
+	switch message.Kind {
+	case db.OutboxKindTask:
+		// 使用固定的任务 ID，重复投递时 asynq 返回 ErrTaskIDConflict，说明任务已经在队列中
+		opts := []asynq.Option{asynq.TaskID(fmt.Sprintf("outbox:%d", message.ID))}
+		if message.Queue != "" {
+			opts = append(opts, asynq.Queue(message.Queue))
+		}
+		_, err := relay.distributor.DistributeTask(ctx, message.Topic, json.RawMessage(message.Payload), opts...)
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return nil
+		}
+		return err
+	case db.OutboxKindEvent:
+		// 使用 Stream 而不是 pub/sub，消费方离线期间的事件不会丢失
+		return relay.rdb.XAdd(ctx, &redis.XAddArgs{
+			Stream: message.Topic,
+			MaxLen: relay.opts.StreamMaxLen,
+			Approx: true,
+			Values: map[string]any{
+				"outbox_id": message.ID,
+				"payload":   []byte(message.Payload),
+			},
+		}).Err()
+	default:
+		return fmt.Errorf("unknown outbox message kind %q", message.Kind)
+	}
+}
+
+func (relay *OutboxRelay) cleanup(ctx context.Context) {
+	n, err := relay.store.DeletePublishedOutboxMessages(ctx, time.Now().Add(-relay.opts.Retention))
+	if err != nil {
+		relayLogger().Error("failed to clean up outbox messages", zap.Error(err))
+		return
+	}
+	if n > 0 {
+		relayLogger().Info("outbox messages cleaned up", zap.Int64("deleted", n))
+	}
+}
+
+// relayLogger 可以通过管理接口单独调整 asyncq.outbox 的日志级别
+func relayLogger() *zap.Logger {
+	return logger.L().Named("asyncq.outbox")
+}
@@ -0,0 +1,188 @@
+package asyncq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/a1ostudio/nova/db/mock"
+	db "github.com/a1ostudio/nova/db/sqlc"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// failingDistributor 模拟任务队列不可用
+type failingDistributor struct {
+	calls int
+}
+
+func (distributor *failingDistributor) DistributeTask(context.Context, string, any, ...asynq.Option) (*asynq.TaskInfo, error) {
+	distributor.calls++
+	return nil, errors.New("redis unavailable")
+}
+
+func TestOutboxRelayPublish(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	relay := NewOutboxRelay(nil, NewRedisTaskDistributor(rdb), rdb, RelayOptions{})
+	ctx := context.Background()
+
+	message := db.Outbox{
+		ID:      7,
+		Kind:    db.OutboxKindTask,
+		Topic:   "user:welcome",
+		Queue:   QueueLow,
+		Payload: []byte(`{"user_id":1}`),
+	}
+	require.NoError(t, relay.publish(ctx, message))
+
+	inspector := newTestInspector(t, rdb)
+	task, err := inspector.GetTaskInfo(QueueLow, "outbox:7")
+	require.NoError(t, err)
+	require.Equal(t, "user:welcome", task.Type)
+	require.JSONEq(t, `{"user_id":1}`, string(task.Payload))
+
+	// 重复投递同一条消息时任务 ID 冲突，视为已投递
+	require.NoError(t, relay.publish(ctx, message))
+
+	err = relay.publish(ctx, db.Outbox{
+		ID:      8,
+		Kind:    db.OutboxKindEvent,
+		Topic:   "user.created",
+		Payload: []byte(`{"user_id":1}`),
+	})
+	require.NoError(t, err)
+
+	// 事件写入 stream，消费方上线后仍然可以读取
+	entries, err := rdb.XRange(ctx, "user.created", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "8", entries[0].Values["outbox_id"])
+	require.JSONEq(t, `{"user_id":1}`, entries[0].Values["payload"].(string))
+
+	err = relay.publish(ctx, db.Outbox{ID: 9, Kind: "unknown"})
+	require.Error(t, err)
+}
+
+func TestOutboxRelayBatch(t *testing.T) {
+	const maxAttempts = 3
+
+	testCases := []struct {
+		name       string
+		attempts   int32
+		failing    bool
+		buildStubs func(store *mockdb.MockStore, message db.Outbox)
+		checkCalls func(t *testing.T, calls int)
+	}{
+		{
+			name:     "Published",
+			attempts: 1,
+			buildStubs: func(store *mockdb.MockStore, message db.Outbox) {
+				store.EXPECT().MarkOutboxMessagesPublished(gomock.Any(), []int64{message.ID}).Return(nil)
+				store.EXPECT().MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().MarkOutboxMessageDead(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:     "RetryWithBackoff",
+			attempts: 2,
+			failing:  true,
+			buildStubs: func(store *mockdb.MockStore, message db.Outbox) {
+				store.EXPECT().MarkOutboxMessagesPublished(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, arg db.MarkOutboxMessageFailedParams) error {
+						require.Equal(t, message.ID, arg.ID)
+						require.Equal(t, "redis unavailable", arg.LastError)
+						// 第二次尝试失败后退避 1~2s
+						require.WithinRange(t, arg.AvailableAt, time.Now().Add(900*time.Millisecond), time.Now().Add(2*time.Second))
+						return nil
+					})
+				store.EXPECT().MarkOutboxMessageDead(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCalls: func(t *testing.T, calls int) {
+				require.Equal(t, 1, calls)
+			},
+		},
+		{
+			name:     "DeadAfterMaxAttempts",
+			attempts: maxAttempts,
+			failing:  true,
+			buildStubs: func(store *mockdb.MockStore, message db.Outbox) {
+				store.EXPECT().MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					MarkOutboxMessageDead(gomock.Any(), db.MarkOutboxMessageDeadParams{
+						ID:        message.ID,
+						LastError: "redis unavailable",
+					}).
+					Return(nil)
+			},
+			checkCalls: func(t *testing.T, calls int) {
+				require.Equal(t, 1, calls)
+			},
+		},
+		{
+			name:     "LeaseExhausted",
+			attempts: maxAttempts + 1,
+			failing:  true,
+			buildStubs: func(store *mockdb.MockStore, message db.Outbox) {
+				store.EXPECT().MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					MarkOutboxMessageDead(gomock.Any(), db.MarkOutboxMessageDeadParams{
+						ID:        message.ID,
+						LastError: errLeaseExhausted.Error(),
+					}).
+					Return(nil)
+			},
+			checkCalls: func(t *testing.T, calls int) {
+				// 之前的尝试都没有完成，不再投递
+				require.Zero(t, calls)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			rdb, _ := newTestRedis(t)
+
+			var distributor TaskDistributor = NewRedisTaskDistributor(rdb)
+			failing := &failingDistributor{}
+			if tc.failing {
+				distributor = failing
+			}
+			relay := NewOutboxRelay(store, distributor, rdb, RelayOptions{
+				BatchSize:    10,
+				LeaseTimeout: time.Minute,
+				MaxAttempts:  maxAttempts,
+			})
+
+			message := db.Outbox{
+				ID:       1,
+				Kind:     db.OutboxKindTask,
+				Topic:    "user:welcome",
+				Payload:  []byte(`{"user_id":1}`),
+				Attempts: tc.attempts,
+			}
+			store.EXPECT().
+				ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, arg db.ClaimOutboxMessagesParams) ([]db.Outbox, error) {
+					require.Equal(t, int32(10), arg.BatchSize)
+					require.WithinDuration(t, time.Now().Add(time.Minute), arg.LockedUntil, time.Second)
+					return []db.Outbox{message}, nil
+				})
+			tc.buildStubs(store, message)
+
+			n, err := relay.relayBatch(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+			if tc.checkCalls != nil {
+				tc.checkCalls(t, failing.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package asyncq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
+	"github.com/a1ostudio/nova/internal/pkg/tracing"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// TaskProcessor 拉取并处理后台任务
+type TaskProcessor interface {
+	// Handle 注册任务处理函数，必须在 Start 之前调用
+	Handle(taskType string, handler asynq.Handler)
+	Start() error
+	// Shutdown 停止拉取新任务并等待处理中的任务完成，超过 ShutdownTimeout 时取消任务并放回队列。
+	// ctx 先结束时直接返回 ctx.Err()，关闭过程仍在后台继续
+	Shutdown(ctx context.Context) error
+}
+
+type ProcessorOptions struct {
+	Concurrency     int                  // 并发处理数，默认 10
+	Queues          map[string]int       // 队列及权重，默认 DefaultQueues
+	StrictPriority  bool                 // 严格按权重从高到低拉取
+	PollInterval    time.Duration        // 队列为空时的轮询间隔，以及检查延迟任务和重试任务的间隔，默认 1s
+	RetryDelay      asynq.RetryDelayFunc // 重试间隔，默认 DefaultRetryDelay
+	ShutdownTimeout time.Duration        // 关闭时等待处理中任务的时间，默认 8s，应小于传给 Shutdown 的 ctx 的超时
+}
+
+func (opts ProcessorOptions) withDefaults() ProcessorOptions {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 10
+	}
+	if len(opts.Queues) == 0 {
+		opts.Queues = DefaultQueues
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.RetryDelay == nil {
+		opts.RetryDelay = DefaultRetryDelay
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 8 * time.Second
+	}
+	return opts
+}
+
+// RedisTaskProcessor 基于 asynq.Server，任务的存储结构与 asynq 相同，可以在 asynqmon 中查看和管理
+type RedisTaskProcessor struct {
+	server *asynq.Server
+	mux    *asynq.ServeMux
+	opts   ProcessorOptions
+}
+
+// NewRedisTaskProcessor 使用已有的 Redis 连接处理任务，Redis 连接由调用方关闭
+func NewRedisTaskProcessor(rdb redis.UniversalClient, opts ProcessorOptions) TaskProcessor {
+	opts = opts.withDefaults()
+
+	server := asynq.NewServerFromRedisClient(rdb, asynq.Config{
+		Concurrency:              opts.Concurrency,
+		Queues:                   opts.Queues,
+		StrictPriority:           opts.StrictPriority,
+		TaskCheckInterval:        opts.PollInterval,
+		DelayedTaskCheckInterval: opts.PollInterval,
+		RetryDelayFunc:           opts.RetryDelay,
+		ShutdownTimeout:          opts.ShutdownTimeout,
+		Logger:                   logger.L().Named("asyncq").Sugar(),
+		// 级别由 zap 过滤，可以通过管理接口调整 asyncq 的日志级别
+		LogLevel: asynq.DebugLevel,
+	})
+
+	mux := asynq.NewServeMux()
+	mux.Use(processTask)
+
+	return &RedisTaskProcessor{
+		server: server,
+		mux:    mux,
+		opts:   opts,
+	}
+}
+
+func (processor *RedisTaskProcessor) Handle(taskType string, handler asynq.Handler) {
+	processor.mux.Handle(taskType, handler)
+}
+
+func (processor *RedisTaskProcessor) Start() error {
+	logger.L().Info("Starting task processor...",
+		zap.Int("concurrency", processor.opts.Concurrency),
+		zap.Any("queues", processor.opts.Queues),
+	)
+	return processor.server.Start(processor.mux)
+}
+
+func (processor *RedisTaskProcessor) Shutdown(ctx context.Context) error {
+	logger.L().Info("Shutting down task processor...")
+
+	done := make(chan struct{})
+	go func() {
+		processor.server.Shutdown()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// processTask 延续投递任务时的 trace，注入带有任务信息的 logger，并将 panic 记录后转换为错误
+func processTask(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) (err error) {
+		taskID, _ := asynq.GetTaskID(ctx)
+		queue, _ := asynq.GetQueueName(ctx)
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+		headers := task.Headers()
+
+		ctx = tracing.Extract(ctx, headers)
+		ctx, span := tracing.Tracer().Start(ctx, "asyncq process "+task.Type(),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(taskAttributes(task.Type(), queue, taskID)...),
+		)
+
+		// 处理函数通过 logger.FromContext 获取带有关联字段的 logger
+		fields := []zap.Field{
+			zap.String("task_id", taskID),
+			zap.String("task_type", task.Type()),
+			zap.String("queue", queue),
+		}
+		requestID := headers[headerRequestID]
+		if requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+			ctx = logger.WithRequestID(ctx, requestID)
+		}
+		log := logger.WithFields(append(fields, logger.TraceFields(ctx)...)...).Named("asyncq.task")
+		ctx = logger.WithContext(ctx, log)
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("asyncq: panic in %s handler: %w", task.Type(), recovery.Handle(ctx, recovery.SourceTask, r, map[string]string{
+					"task_type": task.Type(),
+					"task_id":   taskID,
+					"queue":     queue,
+				}))
+			}
+			tracing.EndSpan(span, err, attribute.Int("asyncq.task.retried", retried))
+
+			switch {
+			case err == nil:
+			case errors.Is(err, asynq.SkipRetry) || retried >= maxRetry:
+				log.Error("task failed, archived", zap.Int("retried", retried), zap.Error(err))
+			default:
+				log.Warn("task failed, retry later", zap.Int("retried", retried), zap.Error(err))
+			}
+		}()
+		return next.ProcessTask(ctx, task)
+	})
+}
@@ -0,0 +1,54 @@
+package asyncq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	QueueCritical = "critical"
+	QueueDefault  = "default"
+	QueueLow      = "low"
+)
+
+// DefaultQueues 队列及其权重，权重越大被拉取的概率越高
+var DefaultQueues = map[string]int{
+	QueueCritical: 6,
+	QueueDefault:  3,
+	QueueLow:      1,
+}
+
+// headerRequestID 投递任务的请求 ID，随任务 header 保存，处理任务时写入日志用于关联
+const headerRequestID = "request_id"
+
+// DefaultRetryDelay 指数退避，1s 起步，最长 1 小时，并加入随机抖动
+func DefaultRetryDelay(retried int, _ error, _ *asynq.Task) time.Duration {
+	delay := time.Second << min(retried, 12)
+	delay = min(delay, time.Hour)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// DecodePayload 将任务 payload 解码为 T，解码失败时任务不会重试
+func DecodePayload[T any](task *asynq.Task) (T, error) {
+	var payload T
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return payload, fmt.Errorf("unmarshal %s payload: %w: %w", task.Type(), err, asynq.SkipRetry)
+	}
+	return payload, nil
+}
+
+// HandlerOf 将类型化的处理函数转换为 asynq.Handler，payload 无法解码时不会重试
+func HandlerOf[T any](fn func(ctx context.Context, payload T) error) asynq.HandlerFunc {
+	return func(ctx context.Context, task *asynq.Task) error {
+		payload, err := DecodePayload[T](task)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, payload)
+	}
+}
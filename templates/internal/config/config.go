@@ -17,25 +17,67 @@ const (
 type Config struct {
 	Domain               string        `mapstructure:"SERVER_DOMAIN"` // 服务器地址
 	Port                 int           `mapstructure:"SERVER_PORT"`
-	Env                  Env           `mapstructure:"ENV"` // dev, staging, prod, etc.
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	MigrationURL         string        `mapstructure:"MIGRATION_URL"`  // 数据库迁移地址
-	RedisPassword        string        `mapstructure:"REDIS_PASSWORD"` // Redis 密码
-	RedisPort            int           `mapstructure:"REDIS_PORT"`     // Redis 地址
-	LimitRate            int           `mapstructure:"LIMIT_RATE"`     // 每秒允许的请求数
-	LimitBurst           int           `mapstructure:"LIMIT_BURST"`    // 允许的突发请求数
-	AesSecret            string        `mapstructure:"AES_SECRET"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`    // Token 对称密钥
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`  // 访问令牌有效期
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"` // 刷新令牌有效期
-	InvitationDuration   time.Duration `mapstructure:"INVITATION_DURATION"`    // 邀请函有效期
+	MetricsPort          int           `mapstructure:"METRICS_PORT"` // Prometheus 指标端口，仅内网访问，0 表示不启动
+	Env                  Env           `mapstructure:"ENV"`          // dev, staging, prod, etc.
+	DBSource             string        `mapstructure:"DB_SOURCE" secret:"url"`
+	MigrationURL         string        `mapstructure:"MIGRATION_URL"`                // 数据库迁移地址
+	RedisPassword        string        `mapstructure:"REDIS_PASSWORD" secret:"true"` // Redis 密码
+	RedisPort            int           `mapstructure:"REDIS_PORT"`                   // Redis 地址
+	LimitRate            int           `mapstructure:"LIMIT_RATE"`                   // 每秒允许的请求数
+	LimitBurst           int           `mapstructure:"LIMIT_BURST"`                  // 允许的突发请求数
+	AesSecret            string        `mapstructure:"AES_SECRET" secret:"true"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY" secret:"true"` // Token 对称密钥
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`             // 访问令牌有效期
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`            // 刷新令牌有效期
+	InvitationDuration   time.Duration `mapstructure:"INVITATION_DURATION"`               // 邀请函有效期
 	ImportsPath          string        `mapstructure:"IMPORTS_PATH"`
+	WorkerConcurrency    int           `mapstructure:"WORKER_CONCURRENCY"`                       // 后台任务并发处理数
+	OTLPEndpoint         string        `mapstructure:"OTEL_EXPORTER_OTLP_ENDPOINT" secret:"url"` // OTLP/HTTP 地址，为空时不导出 trace
+	TraceSampleRatio     float64       `mapstructure:"TRACE_SAMPLE_RATIO"`                       // trace 采样率，默认 1，0 表示只采样上游已采样的请求
+	SentryDSN            string        `mapstructure:"SENTRY_DSN" secret:"true"`                 // panic 上报地址，兼容 Sentry 协议，为空时不上报
+
+	// 日志配置
+	LogFormat         string        `mapstructure:"LOG_FORMAT"`          // json 或 console，默认 dev 为 console，其他环境为 json
+	LogOutputs        []string      `mapstructure:"LOG_OUTPUTS"`         // stdout、stderr、file，逗号分隔，默认 dev 为 stdout，其他环境为 stdout,file
+	LogPath           string        `mapstructure:"LOG_PATH"`            // 日志文件路径，默认 logs/app.log，目录不存在时自动创建
+	LogMaxSize        int           `mapstructure:"LOG_MAX_SIZE"`        // 单个日志文件的最大大小（MB），超过后轮转，默认 100
+	LogMaxAgeDays     int           `mapstructure:"LOG_MAX_AGE_DAYS"`    // 轮转后的日志保留天数，默认 30
+	LogMaxBackups     int           `mapstructure:"LOG_MAX_BACKUPS"`     // 轮转后的日志保留个数，默认 10
+	LogCompress       bool          `mapstructure:"LOG_COMPRESS"`        // 是否 gzip 压缩轮转后的日志，默认 true
+	LogRotateInterval time.Duration `mapstructure:"LOG_ROTATE_INTERVAL"` // 按时间轮转的间隔，默认 24h，0 表示只按大小轮转
+	LogRedactKeys     []string      `mapstructure:"LOG_REDACT_KEYS"`     // 额外需要脱敏的日志字段名，逗号分隔，密码、令牌、手机号等已默认脱敏
+
+	// 请求体大小限制
+	MaxBodyBytes         int64 `mapstructure:"MAX_BODY_BYTES"`         // 请求体的最大字节数，gzip 请求体按压缩后计算，默认 1MB
+	MaxDecompressedBytes int64 `mapstructure:"MAX_DECOMPRESSED_BYTES"` // gzip 请求体解压后的最大字节数，默认 10MB
+
+	// 跨域配置
+	CORSAllowOrigins      []string      `mapstructure:"CORS_ALLOW_ORIGINS"`       // 允许的 origin，逗号分隔，支持 https://*.a1o.studio 通配子域名，默认为 SERVER_DOMAIN 及其子域名
+	CORSAllowMethods      []string      `mapstructure:"CORS_ALLOW_METHODS"`       // 允许的请求方法
+	CORSAllowHeaders      []string      `mapstructure:"CORS_ALLOW_HEADERS"`       // 允许的请求头
+	CORSExposeHeaders     []string      `mapstructure:"CORS_EXPOSE_HEADERS"`      // 允许前端读取的响应头
+	CORSAllowCredentials  bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`   // 允许携带 Cookie，默认 true
+	CORSMaxAge            time.Duration `mapstructure:"CORS_MAX_AGE"`             // 预检请求的缓存时间，默认 12h
+	CORSAdminAllowOrigins []string      `mapstructure:"CORS_ADMIN_ALLOW_ORIGINS"` // 管理接口允许的 origin，为空时与全局相同
+
+	// 错误响应格式
+	ErrorFormat        string `mapstructure:"ERROR_FORMAT"`          // result 或 problem（RFC 9457 problem+json），默认 result，请求的 Accept 包含 application/problem+json 时总是返回 problem+json
+	ProblemTypeBaseURL string `mapstructure:"PROBLEM_TYPE_BASE_URL"` // problem type 的 URI 前缀，默认为 https://SERVER_DOMAIN/problems/
+
+	// 安全响应头配置
+	ContentSecurityPolicy string `mapstructure:"CONTENT_SECURITY_POLICY"` // 覆盖默认的 CSP，可以包含 {nonce}，为空时使用各环境的默认值
 
 	// 分布式锁配置参数
 	LockTTL         time.Duration `mapstructure:"LOCK_TTL"`          // 锁的生存时间，默认 2s
 	MaxWaitTime     time.Duration `mapstructure:"MAX_WAIT_TIME"`     // 等待锁的最大时间，默认 1s
 	InitialWaitTime time.Duration `mapstructure:"INITIAL_WAIT_TIME"` // 初始等待时间，默认 50ms
 	MaxSingleWait   time.Duration `mapstructure:"MAX_SINGLE_WAIT"`   // 单次最大等待时间，默认 200ms
+
+	// 优雅关闭各阶段的超时时间
+	ShutdownPreStopDelay  time.Duration `mapstructure:"SHUTDOWN_PRE_STOP_DELAY"` // 就绪检查失败后等待负载均衡摘除流量的时间，默认 5s
+	ShutdownHTTPTimeout   time.Duration `mapstructure:"SHUTDOWN_HTTP_TIMEOUT"`   // 等待处理中的 HTTP 请求完成，默认 20s
+	ShutdownWorkerTimeout time.Duration `mapstructure:"SHUTDOWN_WORKER_TIMEOUT"` // 等待后台任务和 outbox 投递完成，默认 30s
+	ShutdownCloseTimeout  time.Duration `mapstructure:"SHUTDOWN_CLOSE_TIMEOUT"`  // 关闭数据库连接池和 Redis，默认 5s
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -51,6 +93,36 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetDefault("INITIAL_WAIT_TIME", "50ms")
 	viper.SetDefault("MAX_SINGLE_WAIT", "200ms")
 
+	viper.SetDefault("WORKER_CONCURRENCY", 10)
+	viper.SetDefault("METRICS_PORT", 9090)
+	viper.SetDefault("TRACE_SAMPLE_RATIO", 1.0)
+
+	viper.SetDefault("LOG_PATH", "logs/app.log")
+	viper.SetDefault("LOG_MAX_SIZE", 100)
+	viper.SetDefault("LOG_MAX_AGE_DAYS", 30)
+	viper.SetDefault("LOG_MAX_BACKUPS", 10)
+	viper.SetDefault("LOG_COMPRESS", true)
+	viper.SetDefault("LOG_ROTATE_INTERVAL", "24h")
+
+	viper.SetDefault("MAX_BODY_BYTES", 1<<20)
+	viper.SetDefault("MAX_DECOMPRESSED_BYTES", 10<<20)
+
+	viper.SetDefault("ERROR_FORMAT", "result")
+
+	viper.SetDefault("CORS_ALLOW_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	viper.SetDefault("CORS_ALLOW_HEADERS", []string{"Origin", "Content-Type", "Authorization", "X-Request-ID"})
+	viper.SetDefault("CORS_EXPOSE_HEADERS", []string{
+		"Content-Length", "X-Request-ID",
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After",
+	})
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
+	viper.SetDefault("CORS_MAX_AGE", "12h")
+
+	viper.SetDefault("SHUTDOWN_PRE_STOP_DELAY", "5s")
+	viper.SetDefault("SHUTDOWN_HTTP_TIMEOUT", "20s")
+	viper.SetDefault("SHUTDOWN_WORKER_TIMEOUT", "30s")
+	viper.SetDefault("SHUTDOWN_CLOSE_TIMEOUT", "5s")
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const maskedValue = "******"
+
+// Masked 返回以环境变量名为 key 的配置，用于查看生效的配置。
+// 带有 secret:"true" 标签的字段被整体遮盖，secret:"url" 的字段只遮盖连接串中的密码
+func (config Config) Masked() map[string]any {
+	value := reflect.ValueOf(config)
+	typ := value.Type()
+
+	masked := make(map[string]any, typ.NumField())
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+
+		fieldValue := value.Field(i).Interface()
+		switch field.Tag.Get("secret") {
+		case "true":
+			if s, _ := fieldValue.(string); s != "" {
+				fieldValue = maskedValue
+			}
+		case "url":
+			fieldValue = maskURL(fieldValue.(string))
+		}
+		if d, ok := fieldValue.(interface{ String() string }); ok {
+			fieldValue = d.String()
+		}
+		masked[key] = fieldValue
+	}
+	return masked
+}
+
+// keywordPassword 匹配 keyword/value 形式连接串中的密码，如 host=localhost password='p a55'
+var keywordPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// maskURL 遮盖连接串中的密码：
+//   - URL 形式遮盖 userinfo 中的密码和 password 查询参数
+//   - keyword/value 形式（host=... password=...）遮盖 password 的值
+//
+// 其他无法识别的格式整体遮盖
+func maskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		if keywordPassword.MatchString(raw) {
+			return keywordPassword.ReplaceAllString(raw, "${1}"+maskedValue)
+		}
+		if strings.Contains(raw, "=") {
+			// keyword/value 形式且不含密码
+			return raw
+		}
+		return maskedValue
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return maskedValue
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), maskedValue)
+	}
+	query := u.Query()
+	masked := false
+	for key := range query {
+		if strings.EqualFold(key, "password") {
+			query.Set(key, maskedValue)
+			masked = true
+		}
+	}
+	if masked {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/a1ostudio/nova/internal/config"
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/middleware"
+	"github.com/a1ostudio/nova/internal/model"
+	"github.com/a1ostudio/nova/internal/pkg/buildinfo"
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+	"github.com/a1ostudio/nova/internal/pkg/token"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxLogLevelTTL 临时调整日志级别的最长有效期
+const maxLogLevelTTL = 24 * time.Hour
+
+// AdminController 运维接口，仅员工可以访问
+type AdminController struct {
+	config     config.Config
+	tokenMaker token.Maker
+}
+
+func NewAdminController(config config.Config, tokenMaker token.Maker) *AdminController {
+	return &AdminController{config: config, tokenMaker: tokenMaker}
+}
+
+func (ctrl *AdminController) RegisterRoutes(router *gin.RouterGroup) {
+	admin := router.Group("admin", middleware.RequireAuth(ctrl.tokenMaker), middleware.RequireStaff())
+	{
+		admin.GET("log-level", ctrl.getLogLevel)
+		admin.PUT("log-level", ctrl.setLogLevel)
+		admin.DELETE("log-level", ctrl.resetLogLevel)
+		admin.GET("config", ctrl.getConfig)
+		admin.GET("build-info", ctrl.getBuildInfo)
+	}
+}
+
+// GetLogLevel
+//
+//	@Summary		查看日志级别
+//	@Description	返回全局日志级别和按 logger 名称设置的级别
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	resp.Result[model.LogLevelResponse]	"日志级别"
+//	@Failure		401	{object}	resp.HttpError						"未登录"
+//	@Failure		403	{object}	resp.HttpError						"不是员工"
+//	@Router			/v1/admin/log-level [get]
+func (ctrl *AdminController) getLogLevel(c *gin.Context) {
+	resp.Success(c, logLevelResponse())
+}
+
+// SetLogLevel
+//
+//	@Summary		修改日志级别
+//	@Description	修改全局或指定 logger 的日志级别，设置了 ttl 时到期后自动恢复之前的级别
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		model.SetLogLevelRequest				true	"日志级别"
+//	@Success		200		{object}	resp.Result[model.LogLevelResponse]	"修改后的日志级别"
+//	@Failure		400		{object}	resp.HttpError							"ttl 格式错误"
+//	@Failure		401		{object}	resp.HttpError							"未登录"
+//	@Failure		403		{object}	resp.HttpError							"不是员工"
+//	@Failure		422		{object}	resp.HttpError							"参数校验失败"
+//	@Router			/v1/admin/log-level [put]
+func (ctrl *AdminController) setLogLevel(c *gin.Context) {
+	var req model.SetLogLevelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil {
+		resp.InvalidError(c, resp.WithMessage("invalid level"))
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 || ttl > maxLogLevelTTL {
+			resp.InvalidError(c, resp.WithMessage("ttl must be a positive duration no longer than 24h"))
+			return
+		}
+	}
+
+	logger.SetLevelWithTTL(req.Logger, level, ttl)
+	logger.FromContext(c.Request.Context()).Warn("log level changed",
+		zap.Int64("operator", middleware.MustPayloadFrom(c).UserID),
+		zap.String("logger", req.Logger),
+		zap.Stringer("level", level),
+		zap.Duration("ttl", ttl),
+	)
+	resp.Success(c, logLevelResponse())
+}
+
+// ResetLogLevel
+//
+//	@Summary		恢复日志级别
+//	@Description	删除指定 logger 的日志级别，恢复为继承全局级别
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			logger	query		string								true	"logger 名称"
+//	@Success		200		{object}	resp.Result[model.LogLevelResponse]	"修改后的日志级别"
+//	@Failure		400		{object}	resp.HttpError							"缺少 logger"
+//	@Failure		401		{object}	resp.HttpError							"未登录"
+//	@Failure		403		{object}	resp.HttpError							"不是员工"
+//	@Router			/v1/admin/log-level [delete]
+func (ctrl *AdminController) resetLogLevel(c *gin.Context) {
+	name := c.Query("logger")
+	if name == "" {
+		resp.InvalidError(c, resp.WithMessage("logger is required"))
+		return
+	}
+
+	logger.ResetLevel(name)
+	resp.Success(c, logLevelResponse())
+}
+
+// GetConfig
+//
+//	@Summary		查看生效的配置
+//	@Description	返回当前加载的配置，密钥和连接串中的密码已遮盖
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	resp.Result[map[string]any]	"配置"
+//	@Failure		401	{object}	resp.HttpError				"未登录"
+//	@Failure		403	{object}	resp.HttpError				"不是员工"
+//	@Router			/v1/admin/config [get]
+func (ctrl *AdminController) getConfig(c *gin.Context) {
+	resp.Success(c, ctrl.config.Masked())
+}
+
+// GetBuildInfo
+//
+//	@Summary		查看构建信息
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	resp.Result[buildinfo.Info]	"构建信息"
+//	@Failure		401	{object}	resp.HttpError				"未登录"
+//	@Failure		403	{object}	resp.HttpError				"不是员工"
+//	@Router			/v1/admin/build-info [get]
+func (ctrl *AdminController) getBuildInfo(c *gin.Context) {
+	resp.Success(c, buildinfo.Get())
+}
+
+func logLevelResponse() model.LogLevelResponse {
+	levels := logger.Levels()
+
+	res := model.LogLevelResponse{
+		Level:     levels[0].Level.String(),
+		ExpiresAt: expiresAt(levels[0].ExpiresAt),
+		Overrides: make([]model.LogLevelOverride, 0, len(levels)-1),
+	}
+	for _, override := range levels[1:] {
+		res.Overrides = append(res.Overrides, model.LogLevelOverride{
+			Logger:    override.Logger,
+			Level:     override.Level.String(),
+			ExpiresAt: expiresAt(override.ExpiresAt),
+		})
+	}
+	return res
+}
+
+func expiresAt(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
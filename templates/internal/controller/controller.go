@@ -1,7 +1,50 @@
 package controller
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+
+	"github.com/a1ostudio/nova/internal/middleware"
+	"github.com/a1ostudio/nova/internal/pkg/health"
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+	"github.com/a1ostudio/nova/internal/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
 
 type RegisterRoutes interface {
 	RegisterRoutes(router *gin.RouterGroup)
 }
+
+// HealthChecker controller 依赖额外的外部服务时实现此接口，注册的检查项会加入 /readyz
+type HealthChecker interface {
+	RegisterHealthChecks(registry *health.Registry)
+}
+
+// bindJSON 解析请求体，失败时直接写入错误响应并返回 false
+func bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		if middleware.IsBodyTooLarge(err) {
+			resp.RequestEntityTooLargeError(c)
+			return false
+		}
+		var errs validator.ValidationErrors
+		if errors.As(err, &errs) {
+			resp.FailedValidationError(c, resp.WithMessage(validation.Descriptive(errs)))
+			return false
+		}
+		resp.InvalidError(c)
+		return false
+	}
+	return true
+}
+
+// unauthorized 将 AppError 以 401 返回
+func unauthorized(c *gin.Context, err error) {
+	var appErr resp.AppError
+	if errors.As(err, &appErr) {
+		resp.UnauthorizedError(c, resp.WithError(appErr))
+		return
+	}
+	resp.UnauthorizedError(c)
+}
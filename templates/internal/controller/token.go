@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"github.com/a1ostudio/nova/internal/middleware"
+	"github.com/a1ostudio/nova/internal/model"
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+	"github.com/a1ostudio/nova/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenController struct {
+	sessionService *service.SessionService
+	rateLimiter    middleware.RateLimiter
+}
+
+func NewTokenController(sessionService *service.SessionService, rateLimiter middleware.RateLimiter) *TokenController {
+	return &TokenController{sessionService: sessionService, rateLimiter: rateLimiter}
+}
+
+func (ctrl *TokenController) RegisterRoutes(router *gin.RouterGroup) {
+	tokens := router.Group("tokens", middleware.RateLimit(ctrl.rateLimiter, middleware.PolicyAuth))
+	{
+		tokens.POST("renew", ctrl.renewAccessToken)
+	}
+}
+
+// RenewAccessToken
+//
+//	@Summary		刷新令牌
+//	@Description	使用 refresh token 换取新的 access token，refresh token 每次使用后都会轮换
+//	@Tags			Token
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		model.RenewAccessTokenRequest							true	"refresh token"
+//	@Success		200		{object}	resp.Result[model.RenewAccessTokenResponse]	"新的令牌"
+//	@Failure		401		{object}	resp.HttpError										"令牌无效或会话不可用"
+//	@Failure		429		{object}	resp.HttpError										"请求过于频繁"
+//	@Router			/v1/tokens/renew [post]
+func (ctrl *TokenController) renewAccessToken(c *gin.Context) {
+	var req model.RenewAccessTokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	pair, err := ctrl.sessionService.RenewAccessToken(c.Request.Context(), req.RefreshToken, service.SessionMeta{
+		UserAgent: c.Request.UserAgent(),
+		ClientIP:  c.ClientIP(),
+	})
+	if err != nil {
+		resp.HandleErrors(c, err, []resp.ErrorMapping{
+			{Target: resp.ErrTokenExpired, Action: unauthorized},
+			{Target: resp.ErrTokenInvalid, Action: unauthorized},
+			{Target: resp.ErrSessionNotFound, Action: unauthorized},
+			{Target: resp.ErrSessionBlocked, Action: unauthorized},
+			{Target: resp.ErrSessionExpired, Action: unauthorized},
+			{Target: resp.ErrSessionUserIDMismatch, Action: unauthorized},
+		})
+		return
+	}
+
+	resp.Success(c, model.RenewAccessTokenResponse{
+		AccessToken:           pair.AccessToken,
+		AccessTokenExpiresAt:  pair.AccessPayload.ExpiredAt,
+		RefreshToken:          pair.RefreshToken,
+		RefreshTokenExpiresAt: pair.RefreshPayload.ExpiredAt,
+	})
+}
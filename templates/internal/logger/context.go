@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type loggerContextKey struct{}
+
+type requestIDContextKey struct{}
+
+// WithContext returns a copy of ctx that carries l.
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, so services keep request_id
+// and trace fields without holding the *gin.Context. It falls back to the
+// global logger with the trace fields of ctx.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*zap.Logger); ok {
+		return l
+	}
+	if log == nil {
+		return zap.NewNop()
+	}
+	return log.With(TraceFields(ctx)...)
+}
+
+// WithRequestID returns a copy of ctx that carries the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDContextKey{}).(string)
+	return requestID, ok && requestID != ""
+}
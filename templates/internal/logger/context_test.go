@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/a1ostudio/nova/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMain(m *testing.M) {
+	NewLogger(config.Config{Env: config.Dev})
+
+	os.Exit(m.Run())
+}
+
+func TestFromContext(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := zap.New(core).With(zap.String("request_id", "abc"))
+
+	ctx := WithContext(context.Background(), l)
+	FromContext(ctx).Info("hello")
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	require.Equal(t, "abc", entries[0].ContextMap()["request_id"])
+
+	// 没有 logger 时不会返回 nil
+	require.NotNil(t, FromContext(context.Background()))
+}
+
+func TestFromContextTraceFields(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	global := log
+	log = zap.New(core)
+	t.Cleanup(func() { log = global })
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+
+	// ctx 中没有 logger 时使用全局 logger，并加上 ctx 的 trace 字段
+	FromContext(ctx).Info("hello")
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	require.Equal(t, spanCtx.TraceID().String(), entries[0].ContextMap()["trace_id"])
+	require.Equal(t, spanCtx.SpanID().String(), entries[0].ContextMap()["span_id"])
+}
+
+func TestLoggerMiddlewareContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(LoggerMiddleware())
+
+	var (
+		requestID string
+		ctxLogger *zap.Logger
+	)
+	router.GET("/", func(c *gin.Context) {
+		requestID, _ = RequestIDFromContext(c.Request.Context())
+		ctxLogger = FromContext(c.Request.Context())
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Request-ID", "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	require.Equal(t, "req-1", requestID)
+	require.NotNil(t, ctxLogger)
+	// 请求内的日志可以通过 http 单独调整级别
+	require.Equal(t, "http", ctxLogger.Name())
+}
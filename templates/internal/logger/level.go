@@ -0,0 +1,218 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelOverride is a level set at runtime, either globally (empty Logger) or for a named logger.
+type LevelOverride struct {
+	Logger    string
+	Level     zapcore.Level
+	ExpiresAt time.Time // zero if the override does not expire
+}
+
+// levelState is the previous value restored when an override with a TTL expires.
+type levelState struct {
+	level  zapcore.Level
+	exists bool
+}
+
+type namedLevel struct {
+	level     zapcore.Level
+	expiresAt time.Time
+	timer     *time.Timer
+	previous  levelState // restored when timer fires
+}
+
+// levelRegistry holds the global level and per-logger overrides.
+// Overrides match a logger name exactly or as a dotted prefix, the longest match wins.
+type levelRegistry struct {
+	global zap.AtomicLevel
+
+	mu             sync.RWMutex
+	named          map[string]*namedLevel
+	globalExpires  time.Time
+	globalTimer    *time.Timer
+	globalPrevious zapcore.Level // restored when globalTimer fires
+}
+
+var levels = newLevelRegistry(zap.NewAtomicLevelAt(zap.InfoLevel))
+
+func newLevelRegistry(global zap.AtomicLevel) *levelRegistry {
+	return &levelRegistry{
+		global: global,
+		named:  make(map[string]*namedLevel),
+	}
+}
+
+// levelFor returns the effective level of the logger with the given name.
+func (r *levelRegistry) levelFor(name string) zapcore.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name != "" {
+		if override, ok := r.named[name]; ok {
+			return override.level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return r.global.Level()
+}
+
+// minLevel returns the lowest level any logger may log at.
+func (r *levelRegistry) minLevel() zapcore.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	level := r.global.Level()
+	for _, override := range r.named {
+		if override.level < level {
+			level = override.level
+		}
+	}
+	return level
+}
+
+func (r *levelRegistry) set(name string, level zapcore.Level, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name == "" {
+		// Replacing a pending override keeps the level it would have restored,
+		// so stacked or extended overrides still fall back to the baseline.
+		previous := r.global.Level()
+		if r.globalTimer != nil {
+			previous = r.globalPrevious
+			r.globalTimer.Stop()
+			r.globalTimer = nil
+			r.globalExpires = time.Time{}
+		}
+		r.global.SetLevel(level)
+		if ttl > 0 {
+			r.globalExpires = time.Now().Add(ttl)
+			var timer *time.Timer
+			timer = time.AfterFunc(ttl, func() {
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				if r.globalTimer != timer {
+					return
+				}
+				r.global.SetLevel(previous)
+				r.globalTimer = nil
+				r.globalExpires = time.Time{}
+			})
+			r.globalTimer = timer
+			r.globalPrevious = previous
+		}
+		return
+	}
+
+	var previous levelState
+	if current, ok := r.named[name]; ok {
+		previous = levelState{level: current.level, exists: true}
+		if current.timer != nil {
+			current.timer.Stop()
+			previous = current.previous
+		}
+	}
+
+	override := &namedLevel{level: level, previous: previous}
+	if ttl > 0 {
+		override.expiresAt = time.Now().Add(ttl)
+		override.timer = time.AfterFunc(ttl, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.named[name] != override {
+				return
+			}
+			if previous.exists {
+				r.named[name] = &namedLevel{level: previous.level}
+			} else {
+				delete(r.named, name)
+			}
+		})
+	}
+	r.named[name] = override
+}
+
+func (r *levelRegistry) reset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if override, ok := r.named[name]; ok {
+		if override.timer != nil {
+			override.timer.Stop()
+		}
+		delete(r.named, name)
+	}
+}
+
+func (r *levelRegistry) list() []LevelOverride {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	overrides := make([]LevelOverride, 0, len(r.named)+1)
+	overrides = append(overrides, LevelOverride{Level: r.global.Level(), ExpiresAt: r.globalExpires})
+	for name, override := range r.named {
+		overrides = append(overrides, LevelOverride{Logger: name, Level: override.level, ExpiresAt: override.expiresAt})
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Logger < overrides[j].Logger })
+	return overrides
+}
+
+// levelCore filters entries by the effective level of their logger name.
+// The wrapped core must be enabled at the lowest level.
+type levelCore struct {
+	zapcore.Core
+	levels *levelRegistry
+}
+
+func newLevelCore(core zapcore.Core, levels *levelRegistry) zapcore.Core {
+	return &levelCore{Core: core, levels: levels}
+}
+
+func (core *levelCore) Enabled(level zapcore.Level) bool {
+	return level >= core.levels.minLevel()
+}
+
+func (core *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: core.Core.With(fields), levels: core.levels}
+}
+
+func (core *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < core.levels.levelFor(entry.LoggerName) {
+		return checked
+	}
+	return core.Core.Check(entry, checked)
+}
+
+// GetLevel returns the global log level.
+func GetLevel() zapcore.Level {
+	return levels.global.Level()
+}
+
+// SetLevelWithTTL sets the level of the named logger and its children, or the global level if name is empty.
+// If ttl is positive, the previous level is restored once it elapses.
+func SetLevelWithTTL(name string, level zapcore.Level, ttl time.Duration) {
+	levels.set(name, level, ttl)
+}
+
+// ResetLevel removes the override of the named logger.
+func ResetLevel(name string) {
+	levels.reset(name)
+}
+
+// Levels returns the global level followed by the per-logger overrides.
+func Levels() []LevelOverride {
+	return levels.list()
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newLevelTestLogger() (*zap.Logger, *levelRegistry, *observer.ObservedLogs) {
+	registry := newLevelRegistry(zap.NewAtomicLevelAt(zap.InfoLevel))
+	inner, logs := observer.New(zap.DebugLevel)
+	return zap.New(newLevelCore(inner, registry)), registry, logs
+}
+
+func TestLevelCore(t *testing.T) {
+	l, registry, logs := newLevelTestLogger()
+	registry.set("asyncq", zap.DebugLevel, 0)
+	registry.set("asyncq.noisy", zap.ErrorLevel, 0)
+
+	l.Debug("root debug")
+	l.Named("asyncq").Debug("asyncq debug")
+	l.Named("asyncq").Named("worker").Debug("worker debug")
+	l.Named("asyncq").Named("noisy").Warn("noisy warn")
+	l.Named("asyncqx").Debug("other debug")
+	l.With(zap.String("k", "v")).Named("asyncq").Debug("with debug")
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	require.Equal(t, []string{"asyncq debug", "worker debug", "with debug"}, messages)
+
+	registry.reset("asyncq")
+	require.Equal(t, zap.InfoLevel, registry.minLevel())
+}
+
+func TestLevelTTL(t *testing.T) {
+	_, registry, _ := newLevelTestLogger()
+
+	registry.set("", zap.DebugLevel, 50*time.Millisecond)
+	registry.set("db", zap.WarnLevel, 0)
+	registry.set("db", zap.DebugLevel, 50*time.Millisecond)
+
+	overrides := registry.list()
+	require.Len(t, overrides, 2)
+	require.Equal(t, zap.DebugLevel, overrides[0].Level)
+	require.False(t, overrides[0].ExpiresAt.IsZero())
+	require.Equal(t, "db", overrides[1].Logger)
+
+	require.Eventually(t, func() bool {
+		return registry.global.Level() == zap.InfoLevel && registry.levelFor("db") == zapcore.WarnLevel
+	}, time.Second, 10*time.Millisecond)
+	require.True(t, registry.list()[1].ExpiresAt.IsZero())
+}
+
+func TestLevelTTLStacked(t *testing.T) {
+	_, registry, _ := newLevelTestLogger()
+
+	// 到期前再次设置或延长，到期后恢复到第一次设置之前的级别
+	registry.set("", zap.DebugLevel, time.Hour)
+	registry.set("", zap.WarnLevel, 50*time.Millisecond)
+	registry.set("db", zap.WarnLevel, 0)
+	registry.set("db", zap.DebugLevel, time.Hour)
+	registry.set("db", zap.ErrorLevel, 50*time.Millisecond)
+	registry.set("cache", zap.DebugLevel, time.Hour)
+	registry.set("cache", zap.DebugLevel, 50*time.Millisecond)
+
+	require.Equal(t, zap.WarnLevel, registry.global.Level())
+	require.Equal(t, zap.ErrorLevel, registry.levelFor("db"))
+
+	require.Eventually(t, func() bool {
+		return registry.global.Level() == zap.InfoLevel &&
+			registry.levelFor("db") == zap.WarnLevel &&
+			registry.levelFor("cache") == zap.InfoLevel
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, registry.list(), 2)
+}
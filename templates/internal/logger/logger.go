@@ -1,11 +1,15 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/a1ostudio/nova/internal/config"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -17,52 +21,94 @@ import (
 )
 
 var (
-	log       *zap.Logger
-	atomicLvl zap.AtomicLevel
-	once      sync.Once
+	log          *zap.Logger
+	closeOutputs = func() error { return nil }
+	once         sync.Once
 )
 
-// Init initializes the global logger.
-func NewLogger(env config.Env) {
+// NewLogger initializes the global logger from the log settings in cfg.
+func NewLogger(cfg config.Config) {
 	once.Do(func() {
-		level := zap.InfoLevel
-		if env == config.Dev {
-			level = zap.DebugLevel
-		}
-		atomicLvl = zap.NewAtomicLevelAt(level)
-
-		cfg := zap.Config{
-			Level:            atomicLvl,
-			Development:      env == config.Dev,
-			Encoding:         "json",
-			OutputPaths:      []string{"stdout", "logs/app.log"},
-			ErrorOutputPaths: []string{"stderr"},
-			EncoderConfig: zapcore.EncoderConfig{
-				TimeKey:      "time",
-				LevelKey:     "level",
-				MessageKey:   "message",
-				CallerKey:    "caller",
-				EncodeLevel:  zapcore.CapitalLevelEncoder,
-				EncodeTime:   zapcore.ISO8601TimeEncoder,
-				EncodeCaller: zapcore.ShortCallerEncoder,
-			},
-		}
-
-		if env == "dev" {
-			cfg.Encoding = "console"
-			cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-			cfg.OutputPaths = []string{"stdout"}
-		}
-
-		logger, err := cfg.Build()
+		logger, closeFn, err := build(cfg)
 		if err != nil {
 			panic(err)
 		}
 		log = logger
+		closeOutputs = closeFn
 		zap.ReplaceGlobals(log)
 	})
 }
 
+// build returns the logger and a function that releases its outputs.
+func build(cfg config.Config) (*zap.Logger, func() error, error) {
+	dev := cfg.Env == config.Dev
+
+	level := zap.InfoLevel
+	if dev {
+		level = zap.DebugLevel
+	}
+	levels = newLevelRegistry(zap.NewAtomicLevelAt(level))
+
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		MessageKey:     "message",
+		CallerKey:      "caller",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	format := cfg.LogFormat
+	if format == "" {
+		format = "json"
+		if dev {
+			format = "console"
+		}
+	}
+
+	var encoder zapcore.Encoder
+	switch format {
+	case "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case "console":
+		if dev {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	outputs := cfg.LogOutputs
+	if len(outputs) == 0 {
+		outputs = []string{outputStdout, outputFile}
+		if dev {
+			outputs = []string{outputStdout}
+		}
+	}
+	sink, closeSink, err := newWriteSyncer(cfg, outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := []zap.Option{
+		zap.AddCaller(),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+		zap.AddStacktrace(zap.ErrorLevel),
+	}
+	if dev {
+		opts = append(opts, zap.Development(), zap.AddStacktrace(zap.WarnLevel))
+	}
+	core := newRedactCore(zapcore.NewCore(encoder, sink, zap.DebugLevel), cfg.LogRedactKeys)
+	core = newLevelCore(core, levels)
+	return zap.New(core, opts...), closeSink, nil
+}
+
 // L returns the global logger.
 func L() *zap.Logger {
 	return log
@@ -73,6 +119,18 @@ func WithRequest(requestID string) *zap.Logger {
 	return log.With(zap.String("request_id", requestID))
 }
 
+// TraceFields returns trace_id and span_id of the span in ctx, or nil if there is none.
+func TraceFields(ctx context.Context) []zap.Field {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanCtx.TraceID().String()),
+		zap.String("span_id", spanCtx.SpanID().String()),
+	}
+}
+
 // WithFields adds custom fields to the logger.
 func WithFields(fields ...zap.Field) *zap.Logger {
 	return log.With(fields...)
@@ -80,7 +138,7 @@ func WithFields(fields ...zap.Field) *zap.Logger {
 
 // SetLevel allows dynamic log level adjustment.
 func SetLevel(level zapcore.Level) {
-	atomicLvl.SetLevel(level)
+	levels.set("", level, 0)
 }
 
 // Sync flushes the logger's buffer.
@@ -90,6 +148,14 @@ func Sync() {
 	}
 }
 
+// Close flushes the logger, stops time-based rotation and closes log files.
+// Call it once on exit; later writes reopen the file but are no longer
+// rotated by time.
+func Close() {
+	Sync()
+	_ = closeOutputs()
+}
+
 // shortPath trims query strings and long IDs in URL paths for cleaner logging.
 func shortPath(path string) string {
 	u, err := url.Parse(path)
@@ -126,10 +192,14 @@ func LoggerMiddleware() gin.HandlerFunc {
 		if requestID == "" {
 			requestID = uuid.New().String()
 		}
-		logger := WithRequest(requestID)
+		logger := WithRequest(requestID).Named("http").With(TraceFields(c.Request.Context())...)
 		c.Set("logger", logger)
 		c.Writer.Header().Set("X-Request-ID", requestID)
 
+		// 放到 request context 中，service 和后台任务可以通过 FromContext 获取
+		ctx := WithRequestID(c.Request.Context(), requestID)
+		c.Request = c.Request.WithContext(WithContext(ctx, logger))
+
 		start := time.Now()
 		c.Next()
 		duration := time.Since(start)
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/config"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	outputStdout = "stdout"
+	outputStderr = "stderr"
+	outputFile   = "file"
+)
+
+// newWriteSyncer builds the log sink from the configured outputs. The
+// returned close function stops background rotation and closes log files.
+func newWriteSyncer(cfg config.Config, outputs []string) (zapcore.WriteSyncer, func() error, error) {
+	syncers := make([]zapcore.WriteSyncer, 0, len(outputs))
+	var files []*rotatingFile
+	closeFiles := func() error {
+		var errs []error
+		for _, file := range files {
+			errs = append(errs, file.Close())
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, output := range outputs {
+		switch output {
+		case outputStdout:
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case outputStderr:
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		case outputFile:
+			file, err := newRotatingFile(cfg)
+			if err != nil {
+				_ = closeFiles()
+				return nil, nil, err
+			}
+			files = append(files, file)
+			syncers = append(syncers, zapcore.AddSync(file))
+		default:
+			_ = closeFiles()
+			return nil, nil, fmt.Errorf("unknown log output %q", output)
+		}
+	}
+	return zapcore.NewMultiWriteSyncer(syncers...), closeFiles, nil
+}
+
+// rotatingFile is a lumberjack file that is also rotated on a fixed
+// interval. Close stops the rotation goroutine before closing the file.
+type rotatingFile struct {
+	*lumberjack.Logger
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newRotatingFile opens the log file, rotating it by size and, when
+// LogRotateInterval is set, by time. Rotated files older than LogMaxAgeDays
+// or beyond LogMaxBackups are removed, and gzipped if LogCompress is set.
+// Backup names and rotation boundaries both use UTC.
+func newRotatingFile(cfg config.Config) (*rotatingFile, error) {
+	path := cfg.LogPath
+	if path == "" {
+		path = "logs/app.log"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+
+	file := &rotatingFile{
+		Logger: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    cfg.LogMaxSize,
+			MaxAge:     cfg.LogMaxAgeDays,
+			MaxBackups: cfg.LogMaxBackups,
+			Compress:   cfg.LogCompress,
+		},
+		done: make(chan struct{}),
+	}
+	// 启动时就打开文件，路径不可写时尽早失败
+	if _, err := file.Write(nil); err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+
+	if cfg.LogRotateInterval > 0 {
+		file.wg.Add(1)
+		go file.rotateEvery(cfg.LogRotateInterval)
+	}
+	return file, nil
+}
+
+// rotateEvery rotates the file at each multiple of interval, e.g. at midnight
+// UTC for 24h, so rotated files line up with calendar periods. It returns
+// once the file is closed.
+func (file *rotatingFile) rotateEvery(interval time.Duration) {
+	defer file.wg.Done()
+
+	now := time.Now()
+	timer := time.NewTimer(now.Truncate(interval).Add(interval).Sub(now))
+	defer timer.Stop()
+	select {
+	case <-file.done:
+		return
+	case <-timer.C:
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := file.Rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "rotate log file: %v\n", err)
+		}
+		select {
+		case <-file.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close stops time-based rotation and closes the file. It waits for an
+// in-flight rotation so the file is not reopened after Close returns.
+func (file *rotatingFile) Close() error {
+	file.closeOnce.Do(func() {
+		close(file.done)
+	})
+	file.wg.Wait()
+	return file.Logger.Close()
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "logs", "app.log")
+
+	l, closeFn, err := build(config.Config{
+		Env:        config.Prod,
+		LogOutputs: []string{outputFile},
+		LogPath:    path,
+		LogMaxSize: 1,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = closeFn() })
+
+	l.Info("hello")
+	require.NoError(t, l.Sync())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"message":"hello"`)
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	file, err := newRotatingFile(config.Config{
+		LogPath:       filepath.Join(dir, "app.log"),
+		LogMaxBackups: 1,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = file.Close() })
+
+	_, err = file.Write([]byte("first\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Rotate())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
+
+func TestRotateEvery(t *testing.T) {
+	dir := t.TempDir()
+	file, err := newRotatingFile(config.Config{
+		LogPath:           filepath.Join(dir, "app.log"),
+		LogRotateInterval: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	countFiles := func() int {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		return len(entries)
+	}
+	require.Eventually(t, func() bool {
+		return countFiles() >= 3
+	}, time.Second, 10*time.Millisecond)
+
+	// 关闭后不再按时间轮转
+	require.NoError(t, file.Close())
+	n := countFiles()
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, n, countFiles())
+}
+
+func TestBuildInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  config.Config
+	}{
+		{
+			name: "UnknownFormat",
+			cfg:  config.Config{LogFormat: "xml", LogOutputs: []string{outputStdout}},
+		},
+		{
+			name: "UnknownOutput",
+			cfg:  config.Config{LogOutputs: []string{"syslog"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := build(tc.cfg)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,493 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/pkg/util"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// defaultRedactKeys fields whose value is always masked. Keys are compared
+// case-insensitively with "-" and "_" removed, so "Set-Cookie" matches "setcookie".
+var defaultRedactKeys = []string{
+	"password", "passwd", "originalpassword", "newpassword",
+	"secret", "aessecret", "tokensymmetrickey",
+	"token", "accesstoken", "refreshtoken", "apikey", "xapikey",
+	"authorization", "cookie", "setcookie",
+	"phone", "phonenumber",
+}
+
+// redactPatterns values matching these are masked wherever they appear in
+// string fields, errors and messages.
+var redactPatterns = []struct {
+	re   *regexp.Regexp
+	mask func(string) string
+}{
+	{
+		// JWT
+		re:   regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		mask: func(string) string { return redacted },
+	},
+	{
+		// PASETO
+		re:   regexp.MustCompile(`v[1-4]\.(?:local|public)\.[A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)?`),
+		mask: func(string) string { return redacted },
+	},
+	{
+		// 手机号保留前 3 位和后 4 位
+		re:   regexp.MustCompile(`\b` + util.PhonePattern + `\b`),
+		mask: func(phone string) string { return phone[:3] + "****" + phone[7:] },
+	},
+}
+
+// Redact masks tokens and phone numbers in s.
+func Redact(s string) string {
+	for _, pattern := range redactPatterns {
+		s = pattern.re.ReplaceAllStringFunc(s, pattern.mask)
+	}
+	return s
+}
+
+// redactCore masks sensitive fields before they reach the wrapped core.
+// Keys are matched at any depth: nested zap.Object and zap.Array values are
+// redacted through wrapped encoders, and zap.Any or zap.Reflect values that
+// contain a sensitive key or string are redacted in their JSON form.
+type redactCore struct {
+	zapcore.Core
+	keys map[string]struct{}
+}
+
+func newRedactCore(core zapcore.Core, extraKeys []string) zapcore.Core {
+	keys := make(map[string]struct{}, len(defaultRedactKeys)+len(extraKeys))
+	for _, key := range defaultRedactKeys {
+		keys[normalizeKey(key)] = struct{}{}
+	}
+	for _, key := range extraKeys {
+		keys[normalizeKey(key)] = struct{}{}
+	}
+	return &redactCore{Core: core, keys: keys}
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	return strings.NewReplacer("-", "", "_", "").Replace(key)
+}
+
+func (core *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: core.Core.With(core.redact(fields)), keys: core.keys}
+}
+
+func (core *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return checked.AddCore(entry, core)
+	}
+	return checked
+}
+
+func (core *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = Redact(entry.Message)
+	return core.Core.Write(entry, core.redact(fields))
+}
+
+func (core *redactCore) redact(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		out[i] = core.redactField(field)
+	}
+	return out
+}
+
+func (core *redactCore) redactField(field zapcore.Field) zapcore.Field {
+	if _, ok := core.keys[normalizeKey(field.Key)]; ok {
+		return zap.String(field.Key, redacted)
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = Redact(field.String)
+	case zapcore.ByteStringType:
+		if b, ok := field.Interface.([]byte); ok {
+			return zap.ByteString(field.Key, []byte(Redact(string(b))))
+		}
+	case zapcore.StringerType:
+		if s, ok := field.Interface.(fmt.Stringer); ok {
+			return zap.String(field.Key, Redact(s.String()))
+		}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			if msg := Redact(err.Error()); msg != err.Error() {
+				return zap.NamedError(field.Key, errors.New(msg))
+			}
+		}
+	case zapcore.ObjectMarshalerType:
+		if obj, ok := field.Interface.(zapcore.ObjectMarshaler); ok {
+			return zap.Object(field.Key, redactObject{core: core, obj: obj})
+		}
+	case zapcore.InlineMarshalerType:
+		if obj, ok := field.Interface.(zapcore.ObjectMarshaler); ok {
+			return zap.Inline(redactObject{core: core, obj: obj})
+		}
+	case zapcore.ArrayMarshalerType:
+		if arr, ok := field.Interface.(zapcore.ArrayMarshaler); ok {
+			return zap.Array(field.Key, redactArray{core: core, arr: arr})
+		}
+	case zapcore.ReflectType:
+		return zap.Reflect(field.Key, core.redactValue(field.Interface))
+	}
+	return field
+}
+
+func (core *redactCore) sensitive(key string) bool {
+	_, ok := core.keys[normalizeKey(key)]
+	return ok
+}
+
+// maxRedactDepth bounds the reflection walk, deeper values are treated as
+// sensitive and go through the JSON round-trip.
+const maxRedactDepth = 32
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	timeType          = reflect.TypeFor[time.Time]()
+)
+
+// redactValue masks sensitive keys at any depth of a value logged with
+// zap.Any or zap.Reflect. Values without sensitive keys or strings are
+// returned as is, so the encoder renders them unchanged. Otherwise the value
+// is round-tripped through JSON, which is how the JSON encoder renders it
+// anyway; values that cannot be marshalled are returned unchanged.
+func (core *redactCore) redactValue(v any) any {
+	if !core.needsRedact(reflect.ValueOf(v), 0) {
+		return v
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return v
+	}
+	return core.redactJSON(decoded)
+}
+
+// needsRedact reports whether the JSON form of v may contain a sensitive key
+// or a string matching redactPatterns. Keys follow encoding/json: the json
+// tag name or the field name, with embedded structs flattened.
+func (core *redactCore) needsRedact(v reflect.Value, depth int) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if depth > maxRedactDepth {
+		return true
+	}
+
+	// The output of a custom marshaler is unknown without calling it.
+	if v.Type() != timeType && v.Type().Implements(jsonMarshalerType) {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		return core.needsRedact(v.Elem(), depth+1)
+	case reflect.String:
+		return Redact(v.String()) != v.String()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return false
+		}
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" && !field.Anonymous {
+				name = field.Name
+			}
+			if name != "" && core.sensitive(name) {
+				return true
+			}
+			if core.needsRedact(v.Field(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			if key.Kind() == reflect.String && core.sensitive(key.String()) {
+				return true
+			}
+			if key.Kind() != reflect.String && key.CanInterface() && core.sensitive(fmt.Sprint(key.Interface())) {
+				return true
+			}
+			if core.needsRedact(iter.Value(), depth+1) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded as base64
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+		for i := range v.Len() {
+			if core.needsRedact(v.Index(i), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (core *redactCore) redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if core.sensitive(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = core.redactJSON(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = core.redactJSON(value)
+		}
+	case string:
+		return Redact(v)
+	}
+	return v
+}
+
+// redactObject redacts the keys written by a nested zapcore.ObjectMarshaler.
+type redactObject struct {
+	core *redactCore
+	obj  zapcore.ObjectMarshaler
+}
+
+func (o redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.obj.MarshalLogObject(&redactObjectEncoder{ObjectEncoder: enc, core: o.core})
+}
+
+// redactArray redacts the elements written by a nested zapcore.ArrayMarshaler.
+type redactArray struct {
+	core *redactCore
+	arr  zapcore.ArrayMarshaler
+}
+
+func (a redactArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.arr.MarshalLogArray(&redactArrayEncoder{ArrayEncoder: enc, core: a.core})
+}
+
+// redactObjectEncoder replaces the value of sensitive keys, whatever its type,
+// and masks patterns in string values.
+type redactObjectEncoder struct {
+	zapcore.ObjectEncoder
+	core *redactCore
+}
+
+// mask writes the placeholder instead of the value when key is sensitive.
+func (enc *redactObjectEncoder) mask(key string) bool {
+	if enc.core.sensitive(key) {
+		enc.ObjectEncoder.AddString(key, redacted)
+		return true
+	}
+	return false
+}
+
+func (enc *redactObjectEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	if enc.mask(key) {
+		return nil
+	}
+	return enc.ObjectEncoder.AddArray(key, redactArray{core: enc.core, arr: arr})
+}
+
+func (enc *redactObjectEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	if enc.mask(key) {
+		return nil
+	}
+	return enc.ObjectEncoder.AddObject(key, redactObject{core: enc.core, obj: obj})
+}
+
+func (enc *redactObjectEncoder) AddReflected(key string, value any) error {
+	if enc.mask(key) {
+		return nil
+	}
+	return enc.ObjectEncoder.AddReflected(key, enc.core.redactValue(value))
+}
+
+func (enc *redactObjectEncoder) AddString(key, value string) {
+	if enc.mask(key) {
+		return
+	}
+	enc.ObjectEncoder.AddString(key, Redact(value))
+}
+
+func (enc *redactObjectEncoder) AddByteString(key string, value []byte) {
+	if enc.mask(key) {
+		return
+	}
+	enc.ObjectEncoder.AddByteString(key, []byte(Redact(string(value))))
+}
+
+func (enc *redactObjectEncoder) AddBinary(key string, value []byte) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddBinary(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddBool(key string, value bool) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddBool(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddComplex128(key string, value complex128) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddComplex128(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddComplex64(key string, value complex64) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddComplex64(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddDuration(key string, value time.Duration) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddDuration(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddFloat64(key string, value float64) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddFloat64(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddFloat32(key string, value float32) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddFloat32(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddInt(key string, value int) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddInt(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddInt64(key string, value int64) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddInt64(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddInt32(key string, value int32) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddInt32(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddInt16(key string, value int16) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddInt16(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddInt8(key string, value int8) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddInt8(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddTime(key string, value time.Time) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddTime(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddUint(key string, value uint) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddUint(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddUint64(key string, value uint64) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddUint64(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddUint32(key string, value uint32) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddUint32(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddUint16(key string, value uint16) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddUint16(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddUint8(key string, value uint8) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddUint8(key, value)
+	}
+}
+
+func (enc *redactObjectEncoder) AddUintptr(key string, value uintptr) {
+	if !enc.mask(key) {
+		enc.ObjectEncoder.AddUintptr(key, value)
+	}
+}
+
+// redactArrayEncoder masks patterns in string elements and redacts nested
+// objects and arrays.
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	core *redactCore
+}
+
+func (enc *redactArrayEncoder) AppendString(value string) {
+	enc.ArrayEncoder.AppendString(Redact(value))
+}
+
+func (enc *redactArrayEncoder) AppendByteString(value []byte) {
+	enc.ArrayEncoder.AppendByteString([]byte(Redact(string(value))))
+}
+
+func (enc *redactArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	return enc.ArrayEncoder.AppendArray(redactArray{core: enc.core, arr: arr})
+}
+
+func (enc *redactArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	return enc.ArrayEncoder.AppendObject(redactObject{core: enc.core, obj: obj})
+}
+
+func (enc *redactArrayEncoder) AppendReflected(value any) error {
+	return enc.ArrayEncoder.AppendReflected(enc.core.redactValue(value))
+}
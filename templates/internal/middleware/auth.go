@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+	"github.com/a1ostudio/nova/internal/pkg/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	authorizationHeaderKey  = "Authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+// RequireAuth 要求请求必须携带有效的 access token
+func RequireAuth(tokenMaker token.Maker) gin.HandlerFunc {
+	return Auth(tokenMaker, true)
+}
+
+// OptionalAuth 未携带 token 时直接放行，携带了 token 则必须有效
+func OptionalAuth(tokenMaker token.Maker) gin.HandlerFunc {
+	return Auth(tokenMaker, false)
+}
+
+// Auth 从 Authorization 请求头或 SessionKey Cookie 中读取 access token，
+// 校验通过后将 *token.Payload 存入 gin context
+func Auth(tokenMaker token.Maker, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken, err := extractToken(c)
+		if err != nil {
+			resp.UnauthorizedError(c, resp.WithError(resp.ErrTokenInvalid))
+			return
+		}
+
+		if accessToken == "" {
+			if required {
+				resp.UnauthorizedError(c)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(accessToken, token.TokenTypeAccess)
+		if err != nil {
+			if errors.Is(err, resp.ErrTokenExpired) {
+				resp.UnauthorizedError(c, resp.WithError(resp.ErrTokenExpired))
+				return
+			}
+			resp.UnauthorizedError(c, resp.WithError(resp.ErrTokenInvalid))
+			return
+		}
+
+		c.Set(token.AuthPayloadKey, payload)
+		c.Next()
+	}
+}
+
+// RequireStaff 要求当前用户为员工，必须在 RequireAuth 之后使用
+func RequireStaff() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := PayloadFrom(c)
+		if !ok {
+			resp.UnauthorizedError(c)
+			return
+		}
+		if !payload.GetIsStaff() {
+			resp.ForbiddenError(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// extractToken 优先读取 Authorization 请求头，其次读取 Cookie。
+// 两者都不存在时返回空字符串；请求头格式错误时返回 error
+func extractToken(c *gin.Context) (string, error) {
+	authorizationHeader := c.GetHeader(authorizationHeaderKey)
+	if authorizationHeader != "" {
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationTypeBearer {
+			return "", resp.ErrTokenInvalid
+		}
+		return fields[1], nil
+	}
+
+	cookie, err := c.Cookie(token.SessionKey)
+	if err != nil {
+		return "", nil
+	}
+	return cookie, nil
+}
+
+// PayloadFrom 返回当前请求的认证信息，未认证时 ok 为 false
+func PayloadFrom(c *gin.Context) (*token.Payload, bool) {
+	value, exists := c.Get(token.AuthPayloadKey)
+	if !exists {
+		return nil, false
+	}
+	payload, ok := value.(*token.Payload)
+	return payload, ok && payload != nil
+}
+
+// MustPayloadFrom 返回当前请求的认证信息，只能在 RequireAuth 之后使用
+func MustPayloadFrom(c *gin.Context) *token.Payload {
+	payload, ok := PayloadFrom(c)
+	if !ok {
+		panic("middleware: auth payload not found, route is not protected by RequireAuth")
+	}
+	return payload
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+	"github.com/a1ostudio/nova/internal/pkg/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func addAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	userID int64,
+	duration time.Duration,
+	tokenType token.TokenType,
+) {
+	accessToken, payload, err := tokenMaker.CreateToken(userID, 0, duration, tokenType)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, accessToken)
+	request.Header.Set(authorizationHeaderKey, authorizationHeader)
+}
+
+func TestAuth(t *testing.T) {
+	testCases := []struct {
+		name          string
+		required      bool
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			required: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "Bearer", 1, time.Minute, token.TokenTypeAccess)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "1", recorder.Body.String())
+			},
+		},
+		{
+			name:     "Cookie",
+			required: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				accessToken, _, err := tokenMaker.CreateToken(2, 0, time.Minute, token.TokenTypeAccess)
+				require.NoError(t, err)
+				request.AddCookie(&http.Cookie{Name: token.SessionKey, Value: accessToken})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "2", recorder.Body.String())
+			},
+		},
+		{
+			name:     "NoAuthorization",
+			required: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), resp.ErrUnauthorized.Message)
+			},
+		},
+		{
+			name:     "UnsupportedAuthorization",
+			required: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unsupported", 1, time.Minute, token.TokenTypeAccess)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), resp.ErrTokenInvalid.Message)
+			},
+		},
+		{
+			name:     "InvalidAuthorizationFormat",
+			required: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "", 1, time.Minute, token.TokenTypeAccess)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), resp.ErrTokenInvalid.Message)
+			},
+		},
+		{
+			name:     "ExpiredToken",
+			required: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "Bearer", 1, -time.Minute, token.TokenTypeAccess)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), resp.ErrTokenExpired.Message)
+			},
+		},
+		{
+			name:     "RefreshToken",
+			required: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "Bearer", 1, time.Minute, token.TokenTypeRefresh)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), resp.ErrTokenInvalid.Message)
+			},
+		},
+		{
+			name:     "OptionalNoAuthorization",
+			required: false,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "anonymous", recorder.Body.String())
+			},
+		},
+		{
+			name:     "OptionalInvalidToken",
+			required: false,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(authorizationHeaderKey, "Bearer invalid")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			path := "/auth"
+			server.router.GET(
+				path,
+				Auth(server.tokenMaker, tc.required),
+				func(c *gin.Context) {
+					payload, ok := PayloadFrom(c)
+					if !ok {
+						c.String(http.StatusOK, "anonymous")
+						return
+					}
+					c.String(http.StatusOK, "%d", payload.UserID)
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRequireStaff(t *testing.T) {
+	testCases := []struct {
+		name         string
+		isStaff      int16
+		expectedCode int
+	}{
+		{name: "Staff", isStaff: 1, expectedCode: http.StatusOK},
+		{name: "NotStaff", isStaff: 0, expectedCode: http.StatusForbidden},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			path := "/admin"
+			server.router.GET(path, RequireAuth(server.tokenMaker), RequireStaff(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			accessToken, _, err := server.tokenMaker.CreateToken(1, tc.isStaff, time.Minute, token.TokenTypeAccess)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			request.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
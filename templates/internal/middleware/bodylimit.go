@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BodyLimitOptions struct {
+	Limit           int64            // 请求体的最大字节数，按传输的字节计算，gzip 请求体按压缩后的大小计算
+	Routes          map[string]int64 // 按路由模板（c.FullPath()）覆盖 Limit，如文件上传
+	MaxDecompressed int64            // gzip 请求体解压后的最大字节数，默认为 Limit 的 10 倍
+}
+
+// BodyLimit 限制请求体大小，超过时返回 413，并支持 Content-Encoding: gzip 的请求体。
+//
+// Content-Length 超过限制时直接拒绝；未声明长度或声明不实时，读取超过限制后返回 *http.MaxBytesError，
+// 处理函数应通过 IsBodyTooLarge 判断并返回 413。解压后的大小同样受限，防止解压炸弹
+func BodyLimit(opts BodyLimitOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := opts.Limit
+		if n, ok := opts.Routes[c.FullPath()]; ok {
+			limit = n
+		}
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			resp.RequestEntityTooLargeError(c)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		switch encoding := strings.ToLower(strings.TrimSpace(c.Request.Header.Get("Content-Encoding"))); encoding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			maxDecompressed := opts.MaxDecompressed
+			if maxDecompressed <= 0 {
+				maxDecompressed = limit * 10
+			}
+			if !decompressBody(c, maxDecompressed) {
+				return
+			}
+		default:
+			resp.UnsupportedMediaTypeError(c, resp.WithMessage("unsupported content encoding: "+encoding))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// decompressBody 将请求体替换为解压后的内容，gzip 头无效时返回 400
+func decompressBody(c *gin.Context, maxDecompressed int64) bool {
+	body := c.Request.Body
+	reader, err := gzip.NewReader(body)
+	if err != nil {
+		if IsBodyTooLarge(err) {
+			resp.RequestEntityTooLargeError(c)
+			return false
+		}
+		resp.InvalidError(c, resp.WithMessage("invalid gzip body"))
+		return false
+	}
+
+	c.Request.Body = &gzipBody{
+		Reader: http.MaxBytesReader(c.Writer, io.NopCloser(reader), maxDecompressed),
+		gzip:   reader,
+		body:   body,
+	}
+	// 处理函数看到的是解压后的请求体
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	c.Request.ContentLength = -1
+	return true
+}
+
+type gzipBody struct {
+	io.Reader
+	gzip *gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	return errors.Join(b.gzip.Close(), b.body.Close())
+}
+
+// IsBodyTooLarge 判断读取请求体的错误是否因为超过了 BodyLimit 的限制
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestBodyLimit(t *testing.T) {
+	testCases := []struct {
+		name          string
+		path          string
+		buildRequest  func(t *testing.T) *http.Request
+		checkResponse func(t *testing.T, response *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			path: "/echo",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello"))
+				require.NoError(t, err)
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+				require.Equal(t, "hello", response.Body.String())
+			},
+		},
+		{
+			name: "ContentLengthTooLarge",
+			path: "/echo",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("a", 101)))
+				require.NoError(t, err)
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+				require.Contains(t, response.Body.String(), resp.ErrRequestEntityTooLarge.Message)
+			},
+		},
+		{
+			name: "ChunkedTooLarge",
+			path: "/echo",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("a", 101)))
+				require.NoError(t, err)
+				// 未声明长度，只能在读取时发现超限
+				request.ContentLength = -1
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+			},
+		},
+		{
+			name: "RouteOverride",
+			path: "/upload",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", 500)))
+				require.NoError(t, err)
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+				require.Len(t, response.Body.String(), 500)
+			},
+		},
+		{
+			name: "Gzip",
+			path: "/echo",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/echo", bytes.NewReader(gzipBytes(t, []byte("hello gzip"))))
+				require.NoError(t, err)
+				request.Header.Set("Content-Encoding", "gzip")
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+				require.Equal(t, "hello gzip", response.Body.String())
+			},
+		},
+		{
+			name: "GzipBomb",
+			path: "/echo",
+			buildRequest: func(t *testing.T) *http.Request {
+				// 压缩后远小于 Limit，解压后超过 MaxDecompressed
+				body := gzipBytes(t, make([]byte, 10_000))
+				require.Less(t, len(body), 100)
+				request, err := http.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+				require.NoError(t, err)
+				request.Header.Set("Content-Encoding", "gzip")
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+			},
+		},
+		{
+			name: "InvalidGzip",
+			path: "/echo",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/echo", strings.NewReader("not gzip"))
+				require.NoError(t, err)
+				request.Header.Set("Content-Encoding", "gzip")
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		},
+		{
+			name: "UnsupportedEncoding",
+			path: "/echo",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello"))
+				require.NoError(t, err)
+				request.Header.Set("Content-Encoding", "br")
+				return request
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnsupportedMediaType, response.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.router.Use(BodyLimit(BodyLimitOptions{
+				Limit:           100,
+				Routes:          map[string]int64{"/upload": 1000},
+				MaxDecompressed: 1000,
+			}))
+			echo := func(c *gin.Context) {
+				body, err := io.ReadAll(c.Request.Body)
+				if IsBodyTooLarge(err) {
+					resp.RequestEntityTooLargeError(c)
+					return
+				}
+				require.NoError(t, err)
+				c.String(http.StatusOK, string(body))
+			}
+			server.router.POST("/echo", echo)
+			server.router.POST("/upload", echo)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, tc.buildRequest(t))
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
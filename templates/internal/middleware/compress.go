@@ -0,0 +1,329 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+type CompressOptions struct {
+	MinSize      int      // 响应体小于该字节数时不压缩，默认 1024
+	ContentTypes []string // 允许压缩的 Content-Type，支持 text/* 形式，默认 DefaultCompressContentTypes
+	Exempt       []string // 不压缩的路由模板（c.FullPath()），如 SSE 等流式响应
+}
+
+// DefaultCompressContentTypes 默认压缩的响应类型，图片、压缩包等已压缩的格式不在其中
+var DefaultCompressContentTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/*",
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoding 一种压缩算法，编码器通过 sync.Pool 复用
+type encoding struct {
+	name string
+	pool sync.Pool
+}
+
+// encodings 按服务端的偏好排列，客户端 q 值相同时优先使用靠前的算法
+var encodings = []*encoding{
+	{name: "zstd", pool: sync.Pool{New: func() any {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return enc
+	}}},
+	{name: "br", pool: sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(nil, 4)
+	}}},
+	{name: "gzip", pool: sync.Pool{New: func() any {
+		enc, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return enc
+	}}},
+}
+
+// Compress 根据 Accept-Encoding 压缩响应，支持 zstd、br 和 gzip。
+//
+// 响应体先缓存到 MinSize 字节，确定大小和 Content-Type 后才决定是否压缩，因此 LoggerMiddleware 等外层中间件
+// 读取到的状态码不受影响。应挂在 Timeout 之前，Timeout 提交或写入 504 时经过压缩。
+// 处理函数调用 Flush 时会立即决定是否压缩，已经写出的内容不再缓存
+func Compress(opts CompressOptions) gin.HandlerFunc {
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1024
+	}
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = DefaultCompressContentTypes
+	}
+	exempt := make(map[string]struct{}, len(opts.Exempt))
+	for _, route := range opts.Exempt {
+		exempt[route] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := exempt[c.FullPath()]; ok {
+			c.Next()
+			return
+		}
+		// WebSocket 等协议升级不能压缩
+		if c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		enc := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if enc == nil {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		cw := &compressWriter{
+			ResponseWriter: original,
+			opts:           &opts,
+			encoding:       enc,
+			status:         original.Status(),
+		}
+		c.Writer = cw
+
+		completed := false
+		defer func() {
+			c.Writer = original
+			if !completed {
+				// 处理函数 panic，丢弃缓存的响应，交给外层的 RecoverPanic 写入 500
+				cw.discard()
+				return
+			}
+			cw.finish()
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+// negotiateEncoding 按 q 值选择压缩算法，q 值相同时按服务端的偏好，客户端都不接受时返回 nil
+func negotiateEncoding(header string) *encoding {
+	if header == "" {
+		return nil
+	}
+
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name != "" {
+			accepted[name] = q
+		}
+	}
+
+	var best *encoding
+	bestQ := 0.0
+	for _, enc := range encodings {
+		q, ok := accepted[enc.name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// compressWriter 缓存响应体的前 MinSize 字节，达到后决定是否压缩
+type compressWriter struct {
+	gin.ResponseWriter
+
+	opts     *CompressOptions
+	encoding *encoding
+
+	status    int
+	headerNow bool // 处理函数调用过 WriteHeaderNow
+	buf       []byte
+	decided   bool
+	enc       encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 && !w.headerNow && len(w.buf) == 0 {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.headerNow = true
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.opts.MinSize {
+			return len(data), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Size() int {
+	if !w.decided {
+		if len(w.buf) == 0 && !w.headerNow {
+			return -1
+		}
+		return len(w.buf)
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *compressWriter) Written() bool {
+	if !w.decided {
+		return w.headerNow || len(w.buf) > 0
+	}
+	return w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 决定是否压缩，写出响应头和已缓存的响应体
+func (w *compressWriter) decide() error {
+	w.decided = true
+
+	header := w.ResponseWriter.Header()
+	compressible := w.compressible(header)
+	if compressible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if compressible && len(w.buf) >= w.opts.MinSize {
+		header.Set("Content-Encoding", w.encoding.name)
+		header.Del("Content-Length")
+		// 压缩后的内容与原始内容逐字节不同，强 ETag 改为弱 ETag
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.enc = w.encoding.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		if w.headerNow {
+			w.ResponseWriter.WriteHeaderNow()
+		}
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) compressible(header http.Header) bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+	for _, allowed := range w.opts.ContentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// finish 写出剩余的缓存并关闭编码器
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	w.release()
+}
+
+// discard 丢弃未写出的缓存，已经开始压缩时关闭编码器，保证已写出的部分是完整的压缩流
+func (w *compressWriter) discard() {
+	w.buf = nil
+	w.release()
+}
+
+// release 关闭编码器并放回 pool，不再引用本次请求的 ResponseWriter
+func (w *compressWriter) release() {
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	w.enc.Reset(io.Discard)
+	w.encoding.pool.Put(w.enc)
+	w.enc = nil
+}
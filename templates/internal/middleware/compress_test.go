@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: ""},
+		{header: "identity", expected: ""},
+		{header: "gzip", expected: "gzip"},
+		{header: "gzip, deflate, br, zstd", expected: "zstd"},
+		{header: "gzip, br", expected: "br"},
+		{header: "gzip;q=1.0, br;q=0.5", expected: "gzip"},
+		{header: "zstd;q=0, gzip", expected: "gzip"},
+		{header: "*", expected: "zstd"},
+		{header: "*;q=0.5, br;q=0", expected: "zstd"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			enc := negotiateEncoding(tc.header)
+			if tc.expected == "" {
+				require.Nil(t, enc)
+				return
+			}
+			require.NotNil(t, enc)
+			require.Equal(t, tc.expected, enc.name)
+		})
+	}
+}
+
+func decompress(t *testing.T, encoding string, body []byte) string {
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		reader = r
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		r, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer r.Close()
+		reader = r
+	default:
+		return string(body)
+	}
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"name":"nova"},`, 200)
+
+	testCases := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		encoding       string
+		status         int
+		body           string
+	}{
+		{name: "Gzip", path: "/json", acceptEncoding: "gzip", encoding: "gzip", status: http.StatusOK, body: large},
+		{name: "Brotli", path: "/json", acceptEncoding: "br", encoding: "br", status: http.StatusOK, body: large},
+		{name: "Zstd", path: "/json", acceptEncoding: "gzip, br, zstd", encoding: "zstd", status: http.StatusOK, body: large},
+		{name: "NotAccepted", path: "/json", acceptEncoding: "", encoding: "", status: http.StatusOK, body: large},
+		{name: "Small", path: "/small", acceptEncoding: "gzip", encoding: "", status: http.StatusCreated, body: "small"},
+		{name: "ContentType", path: "/png", acceptEncoding: "gzip", encoding: "", status: http.StatusOK, body: large},
+		{name: "EventStream", path: "/sse", acceptEncoding: "gzip", encoding: "", status: http.StatusOK, body: large},
+		{name: "Exempt", path: "/exempt", acceptEncoding: "gzip", encoding: "", status: http.StatusOK, body: large},
+		{name: "NoContent", path: "/empty", acceptEncoding: "gzip", encoding: "", status: http.StatusNoContent, body: ""},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			// 外层中间件读取到的状态码与实际写出的一致
+			var status int
+			server.router.Use(func(c *gin.Context) {
+				c.Next()
+				status = c.Writer.Status()
+			})
+			server.router.Use(Compress(CompressOptions{Exempt: []string{"/exempt"}}))
+			server.router.GET("/json", func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(large))
+			})
+			server.router.GET("/exempt", func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/json", []byte(large))
+			})
+			server.router.GET("/small", func(c *gin.Context) {
+				c.String(http.StatusCreated, "small")
+			})
+			server.router.GET("/png", func(c *gin.Context) {
+				c.Data(http.StatusOK, "image/png", []byte(large))
+			})
+			server.router.GET("/sse", func(c *gin.Context) {
+				c.Header("Content-Type", "text/event-stream")
+				c.String(http.StatusOK, large)
+				c.Writer.Flush()
+			})
+			server.router.GET("/empty", func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			request.Header.Set("Accept-Encoding", tc.acceptEncoding)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.encoding, recorder.Header().Get("Content-Encoding"))
+			if tc.encoding != "" {
+				require.Less(t, recorder.Body.Len(), len(tc.body))
+				require.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+			}
+			require.Equal(t, tc.body, decompress(t, tc.encoding, recorder.Body.Bytes()))
+		})
+	}
+}
+
+func TestCompressWithTimeout(t *testing.T) {
+	large := strings.Repeat("nova ", 1000)
+
+	server := newTestServer(t)
+	server.router.Use(RecoverPanic(false))
+	server.router.Use(Compress(CompressOptions{}))
+	server.router.Use(Timeout(50 * time.Millisecond))
+	server.router.GET("/fast", func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.String(http.StatusOK, large)
+	})
+	server.router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.String(http.StatusOK, large)
+	})
+	server.router.GET("/panic", func(c *gin.Context) {
+		c.String(http.StatusOK, large)
+		panic("boom")
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		request.Header.Set("Accept-Encoding", "gzip")
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/fast")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	require.Equal(t, `W/"v1"`, recorder.Header().Get("ETag"))
+	require.Equal(t, large, decompress(t, "gzip", recorder.Body.Bytes()))
+
+	recorder = serve("/slow")
+	require.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	require.Contains(t, decompress(t, recorder.Header().Get("Content-Encoding"), recorder.Body.Bytes()), "request timed out")
+
+	recorder = serve("/panic")
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.Empty(t, recorder.Header().Get("Content-Encoding"))
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy 跨域策略。
+//
+// AllowOrigins 中的每一项可以是：
+//   - 完整的 origin，如 https://app.a1o.studio，协议、主机和端口必须完全一致
+//   - 通配子域名，如 https://*.a1o.studio，匹配任意层级的子域名，但不匹配 a1o.studio 本身
+//   - 省略协议，如 a1o.studio 或 *.a1o.studio，此时不限制协议，未写端口时也不限制端口
+//   - *，允许任意 origin
+type CORSPolicy struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string      // 允许的请求头
+	ExposeHeaders    []string      // 允许前端读取的响应头
+	AllowCredentials bool          // 允许携带 Cookie
+	MaxAge           time.Duration // 预检请求的缓存时间
+}
+
+// CORSRule 为路径前缀下的路由单独指定跨域策略，通常是路由组的 BasePath()
+type CORSRule struct {
+	PathPrefix string
+	Policy     CORSPolicy
+}
+
+// CORS 按请求路径选择跨域策略，匹配最长的 PathPrefix，都不匹配时使用 defaultPolicy。
+// 预检请求没有对应的路由，因此按路径而不是在路由组上挂载中间件
+func CORS(defaultPolicy CORSPolicy, rules ...CORSRule) gin.HandlerFunc {
+	type handler struct {
+		prefix string
+		fn     gin.HandlerFunc
+	}
+
+	handlers := make([]handler, 0, len(rules))
+	for _, rule := range rules {
+		handlers = append(handlers, handler{
+			prefix: strings.TrimSuffix(rule.PathPrefix, "/"),
+			fn:     newCORS(rule.Policy),
+		})
+	}
+	fallback := newCORS(defaultPolicy)
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		fn, matched := fallback, -1
+		for _, h := range handlers {
+			if len(h.prefix) > matched && hasPathPrefix(path, h.prefix) {
+				fn, matched = h.fn, len(h.prefix)
+			}
+		}
+		fn(c)
+	}
+}
+
+func newCORS(policy CORSPolicy) gin.HandlerFunc {
+	matcher := newOriginMatcher(policy.AllowOrigins)
+	return cors.New(cors.Config{
+		AllowOriginFunc:  matcher.match,
+		AllowMethods:     policy.AllowMethods,
+		AllowHeaders:     policy.AllowHeaders,
+		ExposeHeaders:    policy.ExposeHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           policy.MaxAge,
+	})
+}
+
+// DomainOrigins 返回域名本身及其子域名的 origin，不限制协议。
+// domain 可以带前导点，如 .a1o.studio（Cookie 域名的写法）
+func DomainOrigins(domain string) []string {
+	domain = strings.TrimPrefix(strings.TrimSpace(domain), ".")
+	if domain == "" {
+		return nil
+	}
+	return []string{domain, "*." + domain}
+}
+
+// hasPathPrefix 按路径段匹配前缀，/nova/v1/admin 不匹配 /nova/v1/administrator
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+type originPattern struct {
+	scheme   string // 为空时不限制协议
+	host     string // 通配时为去掉 *. 之后的域名
+	port     string
+	wildcard bool
+}
+
+type originMatcher struct {
+	any      bool
+	patterns []originPattern
+}
+
+func newOriginMatcher(origins []string) originMatcher {
+	var matcher originMatcher
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == "" {
+			continue
+		}
+		if origin == "*" {
+			matcher.any = true
+			continue
+		}
+
+		var pattern originPattern
+		if scheme, rest, ok := strings.Cut(origin, "://"); ok {
+			pattern.scheme = scheme
+			origin = rest
+		}
+		host, port := origin, ""
+		if i := strings.LastIndexByte(origin, ':'); i >= 0 {
+			host, port = origin[:i], origin[i+1:]
+		}
+		if base, ok := strings.CutPrefix(host, "*."); ok {
+			pattern.wildcard = true
+			host = base
+		}
+		pattern.host = host
+		pattern.port = port
+		matcher.patterns = append(matcher.patterns, pattern)
+	}
+	return matcher
+}
+
+func (matcher originMatcher) match(origin string) bool {
+	if matcher.any {
+		return true
+	}
+
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host, port := u.Hostname(), u.Port()
+
+	for _, pattern := range matcher.patterns {
+		if pattern.scheme != "" && (pattern.scheme != u.Scheme || pattern.port != port) {
+			continue
+		}
+		if pattern.scheme == "" && pattern.port != "" && pattern.port != port {
+			continue
+		}
+		if pattern.wildcard {
+			// 按标签边界匹配，evila1o.studio 不匹配 *.a1o.studio
+			if strings.HasSuffix(host, "."+pattern.host) {
+				return true
+			}
+			continue
+		}
+		if host == pattern.host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	matcher := newOriginMatcher([]string{
+		"https://app.a1o.studio",
+		"https://*.a1o.studio",
+		"localhost",
+		"*.example.com:8080",
+	})
+
+	testCases := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://app.a1o.studio", allowed: true},
+		{origin: "https://APP.a1o.studio", allowed: true},
+		{origin: "http://app.a1o.studio", allowed: false},
+		{origin: "https://app.a1o.studio:8443", allowed: false},
+		{origin: "https://api.a1o.studio", allowed: true},
+		{origin: "https://a.b.a1o.studio", allowed: true},
+		{origin: "https://a1o.studio", allowed: false},
+		{origin: "https://evila1o.studio", allowed: false},
+		{origin: "https://a1o.studio.evil.com", allowed: false},
+		{origin: "http://localhost:3000", allowed: true},
+		{origin: "http://localhost", allowed: true},
+		{origin: "http://app.example.com:8080", allowed: true},
+		{origin: "http://app.example.com:9090", allowed: false},
+		{origin: "null", allowed: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.origin, func(t *testing.T) {
+			require.Equal(t, tc.allowed, matcher.match(tc.origin))
+		})
+	}
+}
+
+func TestDomainOrigins(t *testing.T) {
+	// app.env.example 中的 SERVER_DOMAIN
+	origins := DomainOrigins(".a1o.studio")
+	require.Equal(t, []string{"a1o.studio", "*.a1o.studio"}, origins)
+
+	matcher := newOriginMatcher(origins)
+	require.True(t, matcher.match("https://a1o.studio"))
+	require.True(t, matcher.match("https://app.a1o.studio"))
+	require.True(t, matcher.match("http://localhost.a1o.studio:3000"))
+	require.False(t, matcher.match("https://evila1o.studio"))
+
+	require.Equal(t, []string{"a1o.studio", "*.a1o.studio"}, DomainOrigins("a1o.studio"))
+	require.Empty(t, DomainOrigins(""))
+}
+
+func TestCORS(t *testing.T) {
+	policy := CORSPolicy{
+		AllowOrigins:     []string{"https://*.a1o.studio"},
+		AllowMethods:     []string{"GET", "PATCH"},
+		AllowHeaders:     []string{"Authorization", "X-Request-ID"},
+		ExposeHeaders:    []string{"X-Request-ID", "RateLimit-Remaining"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+	admin := policy
+	admin.AllowOrigins = []string{"https://admin.a1o.studio"}
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		origin        string
+		checkResponse func(t *testing.T, response *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Preflight",
+			method: http.MethodOptions,
+			path:   "/v1/users",
+			origin: "https://app.a1o.studio",
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, response.Code)
+				require.Equal(t, "https://app.a1o.studio", response.Header().Get("Access-Control-Allow-Origin"))
+				require.Contains(t, response.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+				require.Contains(t, response.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+				require.Equal(t, "true", response.Header().Get("Access-Control-Allow-Credentials"))
+				require.Equal(t, "3600", response.Header().Get("Access-Control-Max-Age"))
+			},
+		},
+		{
+			name:   "Simple",
+			method: http.MethodGet,
+			path:   "/v1/users",
+			origin: "https://app.a1o.studio",
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+				require.Equal(t, "https://app.a1o.studio", response.Header().Get("Access-Control-Allow-Origin"))
+				require.Contains(t, response.Header().Get("Access-Control-Expose-Headers"), "X-Request-Id")
+			},
+		},
+		{
+			name:   "SuffixOrigin",
+			method: http.MethodGet,
+			path:   "/v1/users",
+			origin: "https://evila1o.studio",
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, response.Code)
+			},
+		},
+		{
+			name:   "GroupPolicy",
+			method: http.MethodOptions,
+			path:   "/v1/admin/config",
+			origin: "https://app.a1o.studio",
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, response.Code)
+			},
+		},
+		{
+			name:   "GroupPolicyAllowed",
+			method: http.MethodOptions,
+			path:   "/v1/admin/config",
+			origin: "https://admin.a1o.studio",
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, response.Code)
+				require.Equal(t, "https://admin.a1o.studio", response.Header().Get("Access-Control-Allow-Origin"))
+			},
+		},
+		{
+			name:   "GroupPrefixBoundary",
+			method: http.MethodGet,
+			path:   "/v1/administrator",
+			origin: "https://app.a1o.studio",
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.router.Use(CORS(policy, CORSRule{PathPrefix: "/v1/admin/", Policy: admin}))
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			server.router.GET("/v1/users", ok)
+			server.router.GET("/v1/admin/config", ok)
+			server.router.GET("/v1/administrator", ok)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+			request.Header.Set("Origin", tc.origin)
+			if tc.method == http.MethodOptions {
+				request.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/a1ostudio/nova/internal/config"
+	"github.com/a1ostudio/nova/internal/logger"
 	"github.com/a1ostudio/nova/internal/pkg/token"
 	"github.com/a1ostudio/nova/internal/pkg/util"
 
@@ -39,6 +40,7 @@ func newTestServer(t *testing.T) *server {
 
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
+	logger.NewLogger(config.Config{Env: config.Dev})
 
 	os.Exit(m.Run())
 }
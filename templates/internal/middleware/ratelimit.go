@@ -1,55 +1,225 @@
 package middleware
 
 import (
+	"context"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/a1ostudio/nova/internal/pkg/crypto"
+	"github.com/a1ostudio/nova/internal/pkg/metrics"
 	"github.com/a1ostudio/nova/internal/pkg/resp"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
-var clients sync.Map
+// Limit 每个 Period 允许 Rate 个请求，最多允许 Burst 个突发请求
+type Limit struct {
+	Rate   int
+	Burst  int
+	Period time.Duration
+}
+
+// PerSecond 每秒 r 个请求，突发 b 个
+func PerSecond(r, b int) Limit {
+	return Limit{Rate: r, Burst: b, Period: time.Second}
+}
+
+// PerMinute 每分钟 r 个请求，突发 b 个
+func PerMinute(r, b int) Limit {
+	return Limit{Rate: r, Burst: b, Period: time.Minute}
+}
+
+// RateLimitResult 一次限流检查的结果
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // 窗口内允许的最大请求数（即 Burst）
+	Remaining  int           // 剩余可用请求数
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+	ResetAfter time.Duration // 恢复到满额需要的时间
+}
+
+// RateLimiter 限流器，key 相同的请求共享同一个配额
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error)
+}
 
 type client struct {
 	limiter  *rate.Limiter
+	mu       sync.Mutex
 	lastSeen time.Time
 }
 
-// RateLimitByIPMiddleware returns a middleware that limits the number of requests
-// from a single IP address to 'r' requests per 'b' seconds.
-func RateLimitByIPMiddleware(r, b int) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		ip := ctx.ClientIP()
+// MemoryRateLimiter 进程内的令牌桶限流器，多副本部署时每个实例各自计数
+type MemoryRateLimiter struct {
+	clients sync.Map
+}
 
-		limiter, _ := clients.LoadOrStore(ip, &client{
-			limiter:  rate.NewLimiter(rate.Limit(r), b),
-			lastSeen: time.Now(),
-		})
-		rl := limiter.(*client)
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{}
+}
 
-		if !rl.limiter.Allow() {
-			resp.TooManyRequestsError(ctx)
-			ctx.Abort()
-			return
-		}
-		ctx.Next()
+func (limiter *MemoryRateLimiter) Allow(_ context.Context, key string, limit Limit) (RateLimitResult, error) {
+	now := time.Now()
+	every := rate.Every(limit.Period / time.Duration(max(limit.Rate, 1)))
+
+	value, _ := limiter.clients.LoadOrStore(key, &client{
+		limiter: rate.NewLimiter(every, limit.Burst),
+	})
+	c := value.(*client)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastSeen = now
+
+	result := RateLimitResult{Limit: limit.Burst}
+	reservation := c.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return result, nil
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		result.RetryAfter = delay
+	} else {
+		result.Allowed = true
+	}
+
+	tokens := c.limiter.TokensAt(now)
+	result.Remaining = max(int(tokens), 0)
+	if missing := float64(limit.Burst) - tokens; missing > 0 {
+		result.ResetAfter = time.Duration(missing / float64(c.limiter.Limit()) * float64(time.Second))
 	}
+	return result, nil
 }
 
-func CleanupClients(interval time.Duration, expiration time.Duration) {
+// Cleanup 定期清理超过 expiration 未访问的 key
+func (limiter *MemoryRateLimiter) Cleanup(interval time.Duration, expiration time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		now := time.Now()
-		clients.Range(func(key, value any) bool {
-			rl := value.(*client)
-			if now.Sub(rl.lastSeen) > expiration {
-				clients.Delete(key)
+		limiter.clients.Range(func(key, value any) bool {
+			c := value.(*client)
+			c.mu.Lock()
+			expired := now.Sub(c.lastSeen) > expiration
+			c.mu.Unlock()
+			if expired {
+				limiter.clients.Delete(key)
 			}
 			return true
 		})
 	}
 }
+
+// KeyFunc 返回请求的限流 key，返回空字符串表示该请求不限流
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP 按客户端 IP 限流
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 已登录用户按 Payload.UserID 限流，未登录时按 IP 限流。
+// 需要放在 RequireAuth 或 OptionalAuth 之后
+func KeyByUser(c *gin.Context) string {
+	if payload, ok := PayloadFrom(c); ok {
+		return "user:" + strconv.FormatInt(payload.UserID, 10)
+	}
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey 按请求头中的 API Key 限流，未携带时按 IP 限流。
+// API Key 以哈希形式存储，避免明文写入 Redis
+func KeyByAPIKey(header string) KeyFunc {
+	return func(c *gin.Context) string {
+		apiKey := c.GetHeader(header)
+		if apiKey == "" {
+			return KeyByIP(c)
+		}
+		return "apikey:" + crypto.Sum256([]byte(apiKey))
+	}
+}
+
+// RateLimitPolicy 声明式限流策略，可以挂在路由组或单个路由上
+type RateLimitPolicy struct {
+	Name  string  // 策略名，作为 key 前缀，不同策略的配额互不影响
+	Limit Limit   // 限流速率
+	Key   KeyFunc // 限流 key，默认 KeyByIP
+}
+
+// 常用策略，可以直接使用或在此基础上修改
+var (
+	PolicyAuth   = RateLimitPolicy{Name: "auth", Limit: PerMinute(10, 5), Key: KeyByIP}    // 登录、刷新令牌等认证接口
+	PolicySMS    = RateLimitPolicy{Name: "sms", Limit: PerMinute(1, 1), Key: KeyByIP}      // 短信验证码
+	PolicyExport = RateLimitPolicy{Name: "export", Limit: PerMinute(5, 2), Key: KeyByUser} // 数据导出
+)
+
+// RateLimit 按策略限流，并在响应中返回 RateLimit-Limit、RateLimit-Remaining、
+// RateLimit-Reset 响应头，被拒绝时额外返回 Retry-After
+func RateLimit(limiter RateLimiter, policy RateLimitPolicy) gin.HandlerFunc {
+	keyFunc := policy.Key
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+	policyName := policy.Name
+	if policyName == "" {
+		policyName = "default"
+	}
+
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if policy.Name != "" {
+			key = policy.Name + ":" + key
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), key, policy.Limit)
+		if err != nil {
+			// 限流器异常时放行，避免影响正常请求
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			metrics.RateLimitRejectedTotal.WithLabelValues(policyName).Inc()
+			header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			resp.TooManyRequestsError(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+var defaultMemoryLimiter = NewMemoryRateLimiter()
+
+// RateLimitByIPMiddleware returns a middleware that limits the number of requests
+// from a single IP address to 'r' requests per second with a burst of 'b'.
+// The limit is kept in process memory, use RateLimitByIP with a RedisRateLimiter
+// to share it between replicas.
+func RateLimitByIPMiddleware(r, b int) gin.HandlerFunc {
+	return RateLimitByIP(defaultMemoryLimiter, PerSecond(r, b))
+}
+
+// RateLimitByIP limits requests per client IP with the given limiter.
+func RateLimitByIP(limiter RateLimiter, limit Limit) gin.HandlerFunc {
+	return RateLimit(limiter, RateLimitPolicy{Limit: limit, Key: KeyByIP})
+}
+
+// CleanupClients 清理 RateLimitByIPMiddleware 使用的进程内限流器
+func CleanupClients(interval time.Duration, expiration time.Duration) {
+	defaultMemoryLimiter.Cleanup(interval, expiration)
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	rateLimitKeyPrefix = "ratelimit:"
+	redisLimitTimeout  = 100 * time.Millisecond // 单次限流检查的 Redis 超时
+	redisLimitCooldown = 5 * time.Second        // Redis 失败后使用进程内限流的时间
+)
+
+// gcraScript 使用 GCRA 算法计算限流，只保存一个理论到达时间（TAT），所有实例共享。
+// KEYS[1] key; ARGV[1] burst, ARGV[2] rate, ARGV[3] period(秒)
+// 返回 {allowed, remaining, retry_after(秒), reset_after(秒)}
+var gcraScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+
+local emission_interval = period / rate
+local burst_offset = emission_interval * burst
+
+local t = redis.call("TIME")
+local now = (t[1] - 1483228800) + (t[2] / 1000000)
+
+local tat = redis.call("GET", KEYS[1])
+if not tat then
+  tat = now
+else
+  tat = tonumber(tat)
+end
+tat = math.max(tat, now)
+
+local new_tat = tat + emission_interval
+local diff = now - (new_tat - burst_offset)
+local remaining = diff / emission_interval
+
+if remaining < 0 then
+  return {0, 0, tostring(-diff), tostring(tat - now)}
+end
+
+local reset_after = new_tat - now
+redis.call("SET", KEYS[1], tostring(new_tat), "EX", math.ceil(reset_after))
+return {1, math.floor(remaining), "0", tostring(reset_after)}
+`)
+
+// RedisRateLimiter 基于 Redis 的 GCRA 限流器，所有实例共享配额。
+// Redis 不可用时降级为进程内限流，并在冷却时间后重新尝试 Redis
+type RedisRateLimiter struct {
+	rdb            *redis.Client
+	fallback       RateLimiter
+	unhealthyUntil atomic.Int64 // unix nano
+}
+
+func NewRedisRateLimiter(rdb *redis.Client, fallback RateLimiter) *RedisRateLimiter {
+	if fallback == nil {
+		fallback = NewMemoryRateLimiter()
+	}
+	return &RedisRateLimiter{
+		rdb:      rdb,
+		fallback: fallback,
+	}
+}
+
+func (limiter *RedisRateLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error) {
+	if time.Now().UnixNano() < limiter.unhealthyUntil.Load() {
+		return limiter.fallback.Allow(ctx, key, limit)
+	}
+
+	redisCtx, cancel := context.WithTimeout(ctx, redisLimitTimeout)
+	defer cancel()
+
+	values, err := gcraScript.Run(redisCtx, limiter.rdb,
+		[]string{rateLimitKeyPrefix + key},
+		limit.Burst, max(limit.Rate, 1), limit.Period.Seconds(),
+	).Slice()
+	if err == nil {
+		var result RateLimitResult
+		result, err = parseGCRAResult(values, limit)
+		if err == nil {
+			return result, nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		// 调用方自己取消了请求，不代表 Redis 不可用，只对本次请求降级
+		return limiter.fallback.Allow(ctx, key, limit)
+	}
+	limiter.unhealthyUntil.Store(time.Now().Add(redisLimitCooldown).UnixNano())
+	logger.FromContext(ctx).Named("ratelimit").Warn("redis rate limiter unavailable, falling back to memory limiter",
+		zap.Duration("cooldown", redisLimitCooldown),
+		zap.Error(err),
+	)
+	return limiter.fallback.Allow(ctx, key, limit)
+}
+
+func parseGCRAResult(values []any, limit Limit) (RateLimitResult, error) {
+	if len(values) != 4 {
+		return RateLimitResult{}, redis.Nil
+	}
+
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	retryAfter, err := parseSeconds(values[2])
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	resetAfter, err := parseSeconds(values[3])
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return RateLimitResult{
+		Allowed:    allowed == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(remaining),
+		RetryAfter: retryAfter,
+		ResetAfter: resetAfter,
+	}, nil
+}
+
+func parseSeconds(value any) (time.Duration, error) {
+	s, _ := value.(string)
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
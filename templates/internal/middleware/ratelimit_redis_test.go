@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/tracing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestRedisLimiter(t *testing.T) (*RedisRateLimiter, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return NewRedisRateLimiter(rdb, NewMemoryRateLimiter()), mr
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	limiter, mr := newTestRedisLimiter(t)
+	ctx := context.Background()
+	limit := PerMinute(60, 3)
+
+	for i := range 3 {
+		result, err := limiter.Allow(ctx, "user:1", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 3, result.Limit)
+		require.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "user:1", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+	require.InDelta(t, time.Second, result.RetryAfter, float64(50*time.Millisecond))
+	require.True(t, mr.Exists(rateLimitKeyPrefix+"user:1"))
+
+	// 不同 key 互不影响
+	result, err = limiter.Allow(ctx, "user:2", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestRedisRateLimiterFallback(t *testing.T) {
+	limiter, mr := newTestRedisLimiter(t)
+	mr.Close()
+
+	limit := PerSecond(1, 1)
+	result, err := limiter.Allow(context.Background(), "ip:127.0.0.1", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = limiter.Allow(context.Background(), "ip:127.0.0.1", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+}
+
+func TestRedisRateLimiterFallbackLogTrace(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{ServiceName: "test", Exporter: tracetest.NewInMemoryExporter()})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	limiter, mr := newTestRedisLimiter(t)
+	limiter.rdb.AddHook(tracing.RedisHook{})
+	mr.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	server := newTestServer(t)
+	server.router.Use(Tracing(), func(c *gin.Context) {
+		// 与 LoggerMiddleware 相同，request context 中的 logger 带有 trace 字段
+		ctx := c.Request.Context()
+		c.Request = c.Request.WithContext(logger.WithContext(ctx, zap.New(core).With(logger.TraceFields(ctx)...)))
+	})
+	server.router.GET("/test", RateLimitByIP(limiter, PerSecond(1, 1)), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	request, err := http.NewRequest(http.MethodGet, "/test", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	server.router.ServeHTTP(httptest.NewRecorder(), request)
+
+	// Redis 不可用时的告警日志可以通过 trace_id 关联到请求
+	entries := logs.FilterMessage("redis rate limiter unavailable, falling back to memory limiter").All()
+	require.Len(t, entries, 1)
+	require.Equal(t, traceID, entries[0].ContextMap()["trace_id"])
+}
+
+func TestRedisRateLimiterCanceled(t *testing.T) {
+	limiter, _ := newTestRedisLimiter(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := limiter.Allow(ctx, "ip:127.0.0.1", PerSecond(1, 1))
+	require.NoError(t, err)
+	// 调用方取消请求不会让限流器切换到进程内限流
+	require.Zero(t, limiter.unhealthyUntil.Load())
+}
+
+func TestRateLimitByIPWithRedis(t *testing.T) {
+	limiter, _ := newTestRedisLimiter(t)
+	server := newTestServer(t)
+	server.router.GET(
+		"/test",
+		RateLimitByIP(limiter, PerSecond(server.config.LimitRate, server.config.LimitBurst)),
+		func(c *gin.Context) {
+			c.String(http.StatusOK, "ok")
+		},
+	)
+
+	request, err := http.NewRequest(http.MethodGet, "/test", nil)
+	require.NoError(t, err)
+	request.RemoteAddr = "127.0.0.1:12345"
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/a1ostudio/nova/internal/pkg/token"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
@@ -60,3 +62,100 @@ func TestRateLimitByIPMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimitPolicy(t *testing.T) {
+	testCases := []struct {
+		name          string
+		policy        RateLimitPolicy
+		setupRequest  func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int)
+		checkResponse func(t *testing.T, recorders []*httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Headers",
+			policy: RateLimitPolicy{Name: "test", Limit: PerMinute(1, 2)},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int) {
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorders[0].Code)
+				require.Equal(t, "2", recorders[0].Header().Get("RateLimit-Limit"))
+				require.Equal(t, "1", recorders[0].Header().Get("RateLimit-Remaining"))
+				require.Empty(t, recorders[0].Header().Get("Retry-After"))
+
+				require.Equal(t, http.StatusOK, recorders[1].Code)
+				require.Equal(t, "0", recorders[1].Header().Get("RateLimit-Remaining"))
+
+				require.Equal(t, http.StatusTooManyRequests, recorders[2].Code)
+				require.Equal(t, "0", recorders[2].Header().Get("RateLimit-Remaining"))
+				require.Equal(t, "60", recorders[2].Header().Get("Retry-After"))
+			},
+		},
+		{
+			name:   "ByUser",
+			policy: RateLimitPolicy{Name: "test", Limit: PerMinute(1, 1), Key: KeyByUser},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int) {
+				// 同一个 IP 下的不同用户各自计数
+				addAuthorization(t, request, tokenMaker, "Bearer", int64(i+1), time.Minute, token.TokenTypeAccess)
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				for _, recorder := range recorders {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				}
+			},
+		},
+		{
+			name:   "ByAPIKey",
+			policy: RateLimitPolicy{Name: "test", Limit: PerMinute(1, 1), Key: KeyByAPIKey("X-API-Key")},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int) {
+				request.Header.Set("X-API-Key", "key")
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorders[0].Code)
+				require.Equal(t, http.StatusTooManyRequests, recorders[1].Code)
+				require.Equal(t, http.StatusTooManyRequests, recorders[2].Code)
+			},
+		},
+		{
+			name: "SkipEmptyKey",
+			policy: RateLimitPolicy{Name: "test", Limit: PerMinute(1, 1), Key: func(c *gin.Context) string {
+				return ""
+			}},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int) {
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				for _, recorder := range recorders {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					require.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+				}
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			path := "/test"
+			server.router.GET(
+				path,
+				OptionalAuth(server.tokenMaker),
+				RateLimit(NewMemoryRateLimiter(), tc.policy),
+				func(c *gin.Context) {
+					c.String(http.StatusOK, "ok")
+				},
+			)
+
+			recorders := make([]*httptest.ResponseRecorder, 3)
+			for j := range recorders {
+				request, err := http.NewRequest(http.MethodGet, path, nil)
+				require.NoError(t, err)
+				request.RemoteAddr = "127.0.0.1:12345"
+				tc.setupRequest(t, request, server.tokenMaker, j)
+
+				recorders[j] = httptest.NewRecorder()
+				server.router.ServeHTTP(recorders[j], request)
+			}
+			tc.checkResponse(t, recorders)
+		})
+	}
+}
@@ -1,24 +1,76 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
 
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
 	"github.com/a1ostudio/nova/internal/pkg/resp"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-func RecoverPanic() gin.HandlerFunc {
+// RecoverPanic 捕获 panic，记录调用栈并上报后返回 500。
+// debug 为 false 时只返回通用错误信息和请求 ID，panic 内容只写入日志，避免泄露内部信息。
+// 客户端断开连接导致的写入失败只记录警告，不再写入响应。
+// http.ErrAbortHandler 会重新 panic，由 net/http 中断连接，客户端不会把截断的响应当作完整的响应
+func RecoverPanic(debug bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
-			if err := recover(); err != nil {
-				msg := fmt.Sprintf("%s", err)
-				resp.ServerError(c, resp.WithMessage(msg))
+			r := recover()
+			if r == nil {
+				return
 			}
-			// 终端后续中间件
-			c.Abort()
+
+			if err, ok := recovery.Value(r).(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				c.Abort()
+				panic(http.ErrAbortHandler)
+			}
+
+			ctx := c.Request.Context()
+			if isBrokenPipe(recovery.Value(r)) {
+				logger.FromContext(ctx).Warn("client disconnected", zap.Any("error", r))
+				c.Abort()
+				return
+			}
+
+			event := recovery.Handle(ctx, recovery.SourceHTTP, r, map[string]string{
+				"route":  c.FullPath(),
+				"method": c.Request.Method,
+			})
+			// 响应已经开始写入时无法再返回错误
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			options := []resp.ErrorOption{}
+			if debug {
+				options = append(options, resp.WithMessage(event.Event.Message))
+			}
+			if requestID, ok := logger.RequestIDFromContext(ctx); ok {
+				options = append(options, resp.WithRequestID(requestID))
+			}
+			resp.ServerError(c, options...)
 		}()
 
 		c.Next()
 	}
 }
+
+// isBrokenPipe 判断 panic 是否由客户端断开连接引起
+func isBrokenPipe(r any) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
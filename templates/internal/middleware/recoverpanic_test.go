@@ -1,9 +1,17 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
+	"github.com/a1ostudio/nova/internal/pkg/resp"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
@@ -12,13 +20,64 @@ import (
 func TestRecoverPanic(t *testing.T) {
 	testCases := []struct {
 		name          string
-		checkResponse func(t *testing.T, response *httptest.ResponseRecorder)
+		debug         bool
+		handler       gin.HandlerFunc
+		checkResponse func(t *testing.T, response *httptest.ResponseRecorder, events []recovery.Event)
 	}{
 		{
-			name: "Panic",
-			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+			name:  "Debug",
+			debug: true,
+			handler: func(c *gin.Context) {
+				panic("test panic")
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder, events []recovery.Event) {
 				require.Equal(t, http.StatusInternalServerError, response.Code)
 				require.Contains(t, response.Body.String(), "test panic")
+				require.Contains(t, response.Body.String(), `"request_id":"req-1"`)
+				require.Len(t, events, 1)
+			},
+		},
+		{
+			name:  "Production",
+			debug: false,
+			handler: func(c *gin.Context) {
+				panic("test panic")
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder, events []recovery.Event) {
+				require.Equal(t, http.StatusInternalServerError, response.Code)
+				require.NotContains(t, response.Body.String(), "test panic")
+				require.Contains(t, response.Body.String(), resp.ErrServerError.Message)
+				require.Contains(t, response.Body.String(), `"request_id":"req-1"`)
+
+				require.Len(t, events, 1)
+				require.Equal(t, recovery.SourceHTTP, events[0].Source)
+				require.Equal(t, "test panic", events[0].Message)
+				require.Equal(t, "req-1", events[0].RequestID)
+				require.Equal(t, "/panic", events[0].Tags["route"])
+				require.NotEmpty(t, events[0].Frames)
+			},
+		},
+		{
+			name: "BrokenPipe",
+			handler: func(c *gin.Context) {
+				panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder, events []recovery.Event) {
+				// 客户端已断开，不写入响应也不上报
+				require.Equal(t, http.StatusOK, response.Code)
+				require.Empty(t, response.Body.String())
+				require.Empty(t, events)
+			},
+		},
+		{
+			name: "NoPanic",
+			handler: func(c *gin.Context) {
+				c.Next()
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder, events []recovery.Event) {
+				// 没有 panic 时不能中断后续的处理函数
+				require.Equal(t, http.StatusNoContent, response.Code)
+				require.Empty(t, events)
 			},
 		},
 	}
@@ -27,22 +86,57 @@ func TestRecoverPanic(t *testing.T) {
 		tc := testCases[i]
 
 		t.Run(tc.name, func(t *testing.T) {
+			reporter := recovery.NewMemoryReporter()
+			recovery.SetReporter(reporter)
+			t.Cleanup(func() { recovery.SetReporter(nil) })
+
 			server := newTestServer(t)
 			path := "/panic"
 			server.router.GET(
 				path,
-				RecoverPanic(),
+				logger.LoggerMiddleware(),
+				RecoverPanic(tc.debug),
+				tc.handler,
 				func(c *gin.Context) {
-					panic("test panic")
+					c.Status(http.StatusNoContent)
 				},
 			)
 
 			recorder := httptest.NewRecorder()
 			request, err := http.NewRequest(http.MethodGet, path, nil)
 			require.NoError(t, err)
+			request.Header.Set("X-Request-ID", "req-1")
 
 			server.router.ServeHTTP(recorder, request)
-			tc.checkResponse(t, recorder)
+			tc.checkResponse(t, recorder, reporter.Events())
+		})
+	}
+}
+
+func TestRecoverPanicAbortHandler(t *testing.T) {
+	reporter := recovery.NewMemoryReporter()
+	recovery.SetReporter(reporter)
+	t.Cleanup(func() { recovery.SetReporter(nil) })
+
+	server := newTestServer(t)
+	server.router.Use(RecoverPanic(false))
+	abort := func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic(http.ErrAbortHandler)
+	}
+	server.router.GET("/abort", abort)
+	server.router.GET("/timeout", Timeout(time.Second), abort)
+
+	for _, path := range []string{"/abort", "/timeout"} {
+		t.Run(path, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			// 交给 net/http 中断连接，不能当作正常完成的响应
+			require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+				server.router.ServeHTTP(httptest.NewRecorder(), request)
+			})
+			require.Empty(t, reporter.Events())
 		})
 	}
 }
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a1ostudio/nova/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          SecureHeadersOptions
+		checkResponse func(t *testing.T, header http.Header)
+	}{
+		{
+			name: "Prod",
+			opts: SecureHeadersPreset(config.Prod),
+			checkResponse: func(t *testing.T, header http.Header) {
+				require.Equal(t, "max-age=63072000; includeSubDomains", header.Get("Strict-Transport-Security"))
+				require.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+				require.Equal(t, "DENY", header.Get("X-Frame-Options"))
+				require.Equal(t, "strict-origin-when-cross-origin", header.Get("Referrer-Policy"))
+				require.NotEmpty(t, header.Get("Permissions-Policy"))
+				require.Equal(t, apiCSP, header.Get("Content-Security-Policy"))
+				require.Empty(t, header.Get("Content-Security-Policy-Report-Only"))
+			},
+		},
+		{
+			name: "Staging",
+			opts: SecureHeadersPreset(config.Staging),
+			checkResponse: func(t *testing.T, header http.Header) {
+				require.Equal(t, "max-age=86400", header.Get("Strict-Transport-Security"))
+				require.Equal(t, apiCSP, header.Get("Content-Security-Policy"))
+			},
+		},
+		{
+			name: "Dev",
+			opts: SecureHeadersPreset(config.Dev),
+			checkResponse: func(t *testing.T, header http.Header) {
+				require.Empty(t, header.Get("Strict-Transport-Security"))
+				require.Empty(t, header.Get("Content-Security-Policy"))
+				require.Equal(t, apiCSP, header.Get("Content-Security-Policy-Report-Only"))
+			},
+		},
+		{
+			name: "Nonce",
+			opts: SecureHeadersOptions{ContentSecurityPolicy: "script-src 'nonce-{nonce}'"},
+			checkResponse: func(t *testing.T, header http.Header) {
+				nonce := header.Get("X-Nonce")
+				require.NotEmpty(t, nonce)
+				require.Equal(t, "script-src 'nonce-"+nonce+"'", header.Get("Content-Security-Policy"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.router.Use(SecureHeaders(tc.opts))
+			server.router.GET("/", func(c *gin.Context) {
+				c.Header("X-Nonce", CSPNonce(c))
+				c.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			tc.checkResponse(t, recorder.Header())
+		})
+	}
+}
+
+func TestSecureHeadersOverride(t *testing.T) {
+	server := newTestServer(t)
+	server.router.Use(SecureHeaders(SecureHeadersPreset(config.Dev)))
+
+	swagger := SecureHeadersPreset(config.Prod)
+	swagger.ContentSecurityPolicy = SwaggerCSP
+	server.router.GET("/swagger", SecureHeaders(swagger), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/swagger", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, SwaggerCSP, recorder.Header().Get("Content-Security-Policy"))
+	require.Empty(t, recorder.Header().Get("Content-Security-Policy-Report-Only"))
+}
+
+func TestCSPNonceUnique(t *testing.T) {
+	server := newTestServer(t)
+	server.router.Use(SecureHeaders(SecureHeadersOptions{ContentSecurityPolicy: "script-src 'nonce-{nonce}'"}))
+	server.router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, CSPNonce(c))
+	})
+
+	nonces := map[string]struct{}{}
+	for range 10 {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		server.router.ServeHTTP(recorder, request)
+		nonces[recorder.Body.String()] = struct{}{}
+	}
+	require.Len(t, nonces, 10)
+}
@@ -1,35 +1,243 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"maps"
+	"net"
+	"net/http"
+	"sync"
 	"time"
 
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
 	"github.com/a1ostudio/nova/internal/pkg/resp"
 
 	"github.com/gin-gonic/gin"
 )
 
+type TimeoutOptions struct {
+	Timeout time.Duration            // 默认超时时间
+	Routes  map[string]time.Duration // 按路由模板（c.FullPath()）覆盖超时时间
+	Exempt  []string                 // 不限制超时的路由模板，如文件上传、SSE 等长连接
+}
+
+// Timeout 为所有路由设置相同的超时时间
 func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return TimeoutWithOptions(TimeoutOptions{Timeout: timeout})
+}
+
+// TimeoutWithOptions 限制处理函数的执行时间，超时后返回 504。
+//
+// 处理函数在独立的 goroutine 中运行，响应先写入缓冲区，只有在超时前完成时才写入客户端，
+// 因此超时后处理函数的写入会被丢弃，不会和 504 响应混在一起。
+// 超时后 request context 被取消，中间件会等待处理函数返回后才结束，避免 gin.Context 被复用时仍在使用。
+// 缓冲区会持有完整的响应，流式响应的路由应加入 Exempt
+func TimeoutWithOptions(opts TimeoutOptions) gin.HandlerFunc {
+	exempt := make(map[string]struct{}, len(opts.Exempt))
+	for _, route := range opts.Exempt {
+		exempt[route] = struct{}{}
+	}
+
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
-		defer cancel()
+		route := c.FullPath()
+		if _, ok := exempt[route]; ok {
+			c.Next()
+			return
+		}
+		timeout := opts.Timeout
+		if d, ok := opts.Routes[route]; ok {
+			timeout = d
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
 
+		// 不使用 context.WithTimeout：必须先标记超时再取消 context，否则响应取消的处理函数可能抢先返回，
+		// 它写入的响应会代替 504 被提交。超时后 ctx.Err() 为 context.Canceled，context.Cause 为 context.DeadlineExceeded
+		parent := c.Request.Context()
+		ctx, cancel := context.WithCancelCause(parent)
+		defer cancel(nil)
 		c.Request = c.Request.WithContext(ctx)
 
+		// 处理函数运行期间不能再读取 c.Request，提前确定超时响应的格式
+		format := resp.NegotiateErrorFormat(c.Request)
+		original := c.Writer
+		tw := newTimeoutWriter(original)
+		c.Writer = tw
+
 		done := make(chan struct{})
+		panicked := make(chan any, 1)
 		go func() {
+			defer close(done)
+			defer func() {
+				if r := recover(); r != nil {
+					panicked <- recovery.Forward(r)
+				}
+			}()
 			c.Next()
-			close(done)
 		}()
 
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timedOut := false
 		select {
-		case <-ctx.Done():
-			if ctx.Err() == context.DeadlineExceeded {
-				resp.TimeoutError(c, resp.WithMessage("request timed out"))
-				c.Abort()
-			}
 		case <-done:
-			// 请求完成
+		case <-timer.C:
+			timedOut = true
+			tw.timeout()
+			cancel(context.DeadlineExceeded)
+			writeTimeout(ctx, original, format)
+			// 处理函数仍然持有 gin.Context，必须等它返回
+			<-done
+		case <-parent.Done():
+			// 客户端已断开，不需要写入响应
+			timedOut = true
+			tw.timeout()
+			cancel(context.Cause(parent))
+			<-done
+		}
+
+		c.Writer = original
+		select {
+		case r := <-panicked:
+			// 交给外层的 RecoverPanic 处理，缓冲区中未提交的响应直接丢弃
+			panic(r)
+		default:
+		}
+		if timedOut {
+			c.Abort()
+			return
 		}
+		tw.commit()
+	}
+}
+
+// writeTimeout 向客户端写入 504。此时处理函数仍在运行，不能使用 gin.Context
+func writeTimeout(ctx context.Context, w gin.ResponseWriter, format resp.ErrorFormat) {
+	options := []resp.ErrorOption{resp.WithMessage("request timed out")}
+	if requestID, ok := logger.RequestIDFromContext(ctx); ok {
+		options = append(options, resp.WithRequestID(requestID))
+	}
+	resp.WriteError(w, format, http.StatusGatewayTimeout, resp.ErrGatewayTimeout, options...)
+	// 立即发送，不等待处理函数返回
+	w.Flush()
+}
+
+// timeoutWriter 缓存处理函数写入的响应头和响应体，超时后丢弃所有写入
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	written  bool
+	timedOut bool
+}
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		ResponseWriter: w,
+		header:         w.Header().Clone(),
+		status:         http.StatusOK,
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush 响应在处理函数返回后才一次性写入，这里什么都不做
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("middleware: hijack is not supported under timeout, add the route to TimeoutOptions.Exempt")
+}
+
+func (w *timeoutWriter) Pusher() http.Pusher {
+	return nil
+}
+
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+}
+
+// commit 将缓冲的响应写入原始的 ResponseWriter
+func (w *timeoutWriter) commit() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dst := w.ResponseWriter.Header()
+	clear(dst)
+	maps.Copy(dst, w.header)
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
 	}
 }
@@ -6,19 +6,85 @@ import (
 	"testing"
 	"time"
 
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTimeout(t *testing.T) {
+	slow := func(c *gin.Context) {
+		select {
+		case <-time.After(100 * time.Millisecond): // 模拟一个慢请求
+		case <-c.Request.Context().Done():
+		}
+		c.Header("X-Handler", "slow")
+		c.String(http.StatusOK, "slow")
+	}
+
 	testCases := []struct {
 		name          string
-		checkResponse func(t *testing.T, response *httptest.ResponseRecorder, code int)
+		path          string
+		handler       gin.HandlerFunc
+		checkResponse func(t *testing.T, response *httptest.ResponseRecorder)
 	}{
 		{
-			name: "Timeout",
-			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder, code int) {
-				require.Equal(t, code, response.Code)
+			name:    "Timeout",
+			path:    "/slow",
+			handler: slow,
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGatewayTimeout, response.Code)
+				require.Contains(t, response.Body.String(), "request timed out")
+				require.Contains(t, response.Body.String(), `"request_id":"req-1"`)
+				// 超时后处理函数写入的内容被丢弃
+				require.NotContains(t, response.Body.String(), "slow")
+				require.Empty(t, response.Header().Get("X-Handler"))
+				require.Equal(t, "req-1", response.Header().Get("X-Request-ID"))
+			},
+		},
+		{
+			name: "Fast",
+			path: "/fast",
+			handler: func(c *gin.Context) {
+				c.Header("X-Handler", "fast")
+				c.String(http.StatusCreated, "fast")
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, response.Code)
+				require.Equal(t, "fast", response.Body.String())
+				require.Equal(t, "fast", response.Header().Get("X-Handler"))
+			},
+		},
+		{
+			name:    "RouteOverride",
+			path:    "/override",
+			handler: slow,
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+				require.Equal(t, "slow", response.Body.String())
+			},
+		},
+		{
+			name:    "Exempt",
+			path:    "/exempt",
+			handler: slow,
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+				require.Equal(t, "slow", response.Body.String())
+			},
+		},
+		{
+			name: "Panic",
+			path: "/panic",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, "partial")
+				panic("boom")
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, response.Code)
+				require.Contains(t, response.Body.String(), resp.ErrServerError.Message)
+				require.NotContains(t, response.Body.String(), "partial")
 			},
 		},
 	}
@@ -28,26 +94,21 @@ func TestTimeout(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			server := newTestServer(t)
-			server.router.Use(Timeout(50 * time.Millisecond))
-			server.router.GET("/slow", func(c *gin.Context) {
-				time.Sleep(100 * time.Millisecond) // 模拟一个慢请求
-				c.String(http.StatusOK, "ok")
-			})
-			server.router.GET("/fast", func(c *gin.Context) {
-				c.String(http.StatusOK, "ok")
-			})
+			server.router.Use(logger.LoggerMiddleware(), RecoverPanic(false))
+			server.router.Use(TimeoutWithOptions(TimeoutOptions{
+				Timeout: 50 * time.Millisecond,
+				Routes:  map[string]time.Duration{"/override": time.Second},
+				Exempt:  []string{"/exempt"},
+			}))
+			server.router.GET(tc.path, tc.handler)
 
 			recorder := httptest.NewRecorder()
-			slow, err := http.NewRequest(http.MethodGet, "/slow", nil)
+			request, err := http.NewRequest(http.MethodGet, tc.path, nil)
 			require.NoError(t, err)
-			server.router.ServeHTTP(recorder, slow)
-			tc.checkResponse(t, recorder, http.StatusGatewayTimeout)
+			request.Header.Set("X-Request-ID", "req-1")
 
-			fast, err := http.NewRequest(http.MethodGet, "/fast", nil)
-			require.NoError(t, err)
-			recorder = httptest.NewRecorder()
-			server.router.ServeHTTP(recorder, fast)
-			tc.checkResponse(t, recorder, http.StatusOK)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
 		})
 	}
 }
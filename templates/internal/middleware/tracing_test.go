@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a1ostudio/nova/internal/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{ServiceName: "test", Exporter: exporter})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	server := newTestServer(t)
+	server.router.Use(Tracing())
+	server.router.GET("/users/:id", func(c *gin.Context) {
+		require.True(t, trace.SpanContextFromContext(c.Request.Context()).IsValid())
+		c.String(http.StatusInternalServerError, "boom")
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	request, err := http.NewRequest(http.MethodGet, "/users/1", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	server.router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /users/:id", spans[0].Name)
+	require.Equal(t, trace.SpanKindServer, spans[0].SpanKind)
+	// 延续上游的 trace
+	require.Equal(t, traceID, spans[0].SpanContext.TraceID().String())
+	require.Equal(t, "Error", spans[0].Status.Code.String())
+}
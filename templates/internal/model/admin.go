@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+type LogLevelOverride struct {
+	Logger    string     `json:"logger" example:"asyncq"`                             // logger 名称，同时作用于其子 logger
+	Level     string     `json:"level" example:"debug"`                               // 日志级别
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-01-01T00:10:00Z"` // 到期后恢复之前的级别，为空表示不过期
+} //	@name	LogLevelOverride
+
+type LogLevelResponse struct {
+	Level     string             `json:"level" example:"info"` // 全局日志级别
+	ExpiresAt *time.Time         `json:"expires_at,omitempty"` // 全局级别的到期时间
+	Overrides []LogLevelOverride `json:"overrides"`            // 按 logger 名称设置的级别
+} //	@name	LogLevelResponse
+
+type SetLogLevelRequest struct {
+	Level  string `json:"level" binding:"required,oneof=debug info warn error dpanic panic fatal" example:"debug"`
+	Logger string `json:"logger" example:"asyncq"` // 为空时修改全局级别，可选 http、http.ratelimit、asyncq、asyncq.task、asyncq.outbox、sentry
+	TTL    string `json:"ttl" example:"10m"`       // 有效期，如 10m、1h，为空表示不过期
+} //	@name	SetLogLevelRequest
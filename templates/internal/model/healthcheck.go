@@ -7,7 +7,13 @@ type System struct {
 	Version     string     `json:"version" example:"1.0.0"`
 } //	@name	System
 
+type ComponentHealth struct {
+	Status    string  `json:"status" example:"up"`       // up / down，失败原因只写入日志
+	LatencyMs float64 `json:"latency_ms" example:"1.25"` // 检查耗时
+} //	@name	ComponentHealth
+
 type Healthcheck struct {
-	Status string `json:"status" example:"available"`
-	System System `json:"system"`
+	Status     string                     `json:"status" example:"available"` // available / degraded
+	System     System                     `json:"system"`
+	Components map[string]ComponentHealth `json:"components,omitempty"` // 各依赖的检查结果
 } //	@name	Healthcheck
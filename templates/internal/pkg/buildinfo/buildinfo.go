@@ -0,0 +1,43 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version 构建时可以通过 -ldflags "-X github.com/a1ostudio/nova/internal/pkg/buildinfo.Version=x.y.z" 覆盖
+var Version = "1.0.0"
+
+type Info struct {
+	Version   string `json:"version" example:"1.0.0"`
+	GoVersion string `json:"go_version" example:"go1.25.3"`
+	Module    string `json:"module" example:"github.com/a1ostudio/nova"`
+	Revision  string `json:"revision,omitempty" example:"4f2a9c1"`                // git commit
+	BuildTime string `json:"build_time,omitempty" example:"2025-01-01T00:00:00Z"` // git commit 时间
+	Modified  bool   `json:"modified"`                                            // 构建时工作区是否有未提交的修改
+} //	@name	BuildInfo
+
+// Get 返回构建信息，git 信息来自 go build 写入的 vcs 设置
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = build.Main.Path
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const DefaultTimeout = 2 * time.Second
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// CheckFunc 检查一个依赖是否可用，返回 nil 表示可用
+type CheckFunc func(ctx context.Context) error
+
+type checker struct {
+	name    string
+	check   CheckFunc
+	timeout time.Duration
+}
+
+// Result 单个依赖的检查结果
+type Result struct {
+	Status  Status
+	Latency time.Duration
+	Error   string
+}
+
+// Report 所有依赖的检查结果
+type Report struct {
+	Status     Status
+	Components map[string]Result
+}
+
+// Registry 保存就绪检查项，检查时并发执行，每一项有独立的超时时间
+type Registry struct {
+	mu       sync.RWMutex
+	checkers []checker
+
+	group     singleflight.Group
+	cacheMu   sync.RWMutex
+	cached    Report
+	checkedAt time.Time
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register 注册检查项，timeout <= 0 时使用 DefaultTimeout
+func (registry *Registry) Register(name string, check CheckFunc, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.checkers = append(registry.checkers, checker{name: name, check: check, timeout: timeout})
+}
+
+// Check 执行所有检查项，任一检查失败时 Report.Status 为 StatusDown
+func (registry *Registry) Check(ctx context.Context) Report {
+	registry.mu.RLock()
+	checkers := registry.checkers
+	registry.mu.RUnlock()
+
+	results := make([]Result, len(checkers))
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, checker)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:     StatusUp,
+		Components: make(map[string]Result, len(checkers)),
+	}
+	for i, checker := range checkers {
+		report.Components[checker.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// CheckCached 在 ttl 内复用上一次的检查结果，用于公开的探针接口，避免每个请求都访问数据库和 Redis。
+// 缓存过期时同时到达的请求只执行一次检查，检查不受调用方取消的影响
+func (registry *Registry) CheckCached(ctx context.Context, ttl time.Duration) Report {
+	if report, ok := registry.cachedReport(ttl); ok {
+		return report
+	}
+
+	report, _, _ := registry.group.Do("check", func() (any, error) {
+		// 等待期间其他请求可能已经刷新了缓存
+		if report, ok := registry.cachedReport(ttl); ok {
+			return report, nil
+		}
+		report := registry.Check(context.WithoutCancel(ctx))
+
+		registry.cacheMu.Lock()
+		registry.cached = report
+		registry.checkedAt = time.Now()
+		registry.cacheMu.Unlock()
+		return report, nil
+	})
+	return report.(Report)
+}
+
+func (registry *Registry) cachedReport(ttl time.Duration) (Report, bool) {
+	registry.cacheMu.RLock()
+	defer registry.cacheMu.RUnlock()
+
+	if registry.checkedAt.IsZero() || time.Since(registry.checkedAt) >= ttl {
+		return Report{}, false
+	}
+	return registry.cached, true
+}
+
+func run(ctx context.Context, checker checker) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- checker.check(ctx)
+	}()
+
+	// 检查函数不响应 ctx 时也按超时返回
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result = Result{Status: StatusUp, Latency: time.Since(start)}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
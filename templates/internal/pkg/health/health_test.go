@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("ok", func(ctx context.Context) error {
+		return nil
+	}, 0)
+	registry.Register("failed", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}, 0)
+	registry.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, 50*time.Millisecond)
+	registry.Register("panic", func(ctx context.Context) error {
+		panic("boom")
+	}, 0)
+
+	start := time.Now()
+	report := registry.Check(context.Background())
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+
+	require.Equal(t, StatusDown, report.Status)
+	require.Len(t, report.Components, 4)
+
+	require.Equal(t, StatusUp, report.Components["ok"].Status)
+	require.Empty(t, report.Components["ok"].Error)
+
+	require.Equal(t, StatusDown, report.Components["failed"].Status)
+	require.Equal(t, "connection refused", report.Components["failed"].Error)
+
+	require.Equal(t, StatusDown, report.Components["slow"].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Components["slow"].Error)
+
+	require.Equal(t, StatusDown, report.Components["panic"].Status)
+	require.Contains(t, report.Components["panic"].Error, "boom")
+}
+
+func TestCheckAllUp(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("ok", func(ctx context.Context) error {
+		return nil
+	}, 0)
+
+	report := registry.Check(context.Background())
+	require.Equal(t, StatusUp, report.Status)
+	require.Equal(t, StatusUp, report.Components["ok"].Status)
+}
+
+func TestCheckCached(t *testing.T) {
+	var calls atomic.Int32
+	registry := NewRegistry()
+	registry.Register("db", func(ctx context.Context) error {
+		calls.Add(1)
+		return ctx.Err()
+	}, 0)
+
+	// 调用方已取消也不影响检查结果
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := registry.CheckCached(ctx, time.Hour)
+	require.Equal(t, StatusUp, report.Status)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Equal(t, StatusUp, registry.CheckCached(context.Background(), time.Hour).Status)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load())
+
+	// 过期后重新检查
+	registry.CheckCached(context.Background(), 0)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestCheckCachedConcurrentRefresh(t *testing.T) {
+	var calls atomic.Int32
+	registry := NewRegistry()
+	registry.Register("db", func(ctx context.Context) error {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}, 0)
+
+	// 缓存过期时并发的请求共享同一次检查
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report := registry.CheckCached(context.Background(), time.Hour)
+			require.Equal(t, StatusUp, report.Status)
+			require.GreaterOrEqual(t, report.Components["db"].Latency, 50*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load())
+}
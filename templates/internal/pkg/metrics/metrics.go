@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry 应用自己的指标注册表，不使用 prometheus 的全局注册表，避免第三方库注册的指标混入
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route template, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served.",
+	})
+
+	RateLimitRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_rejected_total",
+		Help: "Total number of requests rejected by the rate limiter, by policy.",
+	}, []string{"policy"})
+
+	PanicsRecoveredTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "panics_recovered_total",
+		Help: "Total number of recovered panics, by source (http, task).",
+	}, []string{"source"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		RateLimitRejectedTotal,
+		PanicsRecoveredTotal,
+	)
+}
+
+// Handler 暴露 Registry 中的指标，应挂在内部端口上，不对公网开放
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
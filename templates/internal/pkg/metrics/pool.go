@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// RegisterDBPool 注册 pgxpool 连接池指标，每次采集时读取 pool.Stat()
+func RegisterDBPool(pool *pgxpool.Pool) {
+	Registry.MustRegister(&dbPoolCollector{pool: pool})
+}
+
+// RegisterRedisPool 注册 go-redis 连接池指标，每次采集时读取 rdb.PoolStats()
+func RegisterRedisPool(rdb *redis.Client) {
+	Registry.MustRegister(&redisPoolCollector{rdb: rdb})
+}
+
+var (
+	dbAcquireCount         = prometheus.NewDesc("pgxpool_acquire_total", "Cumulative count of successful acquires from the pool.", nil, nil)
+	dbAcquireDuration      = prometheus.NewDesc("pgxpool_acquire_duration_seconds_total", "Total duration of all successful acquires from the pool.", nil, nil)
+	dbCanceledAcquireCount = prometheus.NewDesc("pgxpool_canceled_acquire_total", "Cumulative count of acquires canceled by a context.", nil, nil)
+	dbEmptyAcquireCount    = prometheus.NewDesc("pgxpool_empty_acquire_total", "Cumulative count of acquires that waited for a connection because the pool was empty.", nil, nil)
+	dbAcquiredConns        = prometheus.NewDesc("pgxpool_acquired_conns", "Number of currently acquired connections.", nil, nil)
+	dbConstructingConns    = prometheus.NewDesc("pgxpool_constructing_conns", "Number of connections being established.", nil, nil)
+	dbIdleConns            = prometheus.NewDesc("pgxpool_idle_conns", "Number of currently idle connections.", nil, nil)
+	dbTotalConns           = prometheus.NewDesc("pgxpool_total_conns", "Total number of connections in the pool.", nil, nil)
+	dbMaxConns             = prometheus.NewDesc("pgxpool_max_conns", "Maximum size of the pool.", nil, nil)
+
+	redisHits       = prometheus.NewDesc("redis_pool_hits_total", "Number of times a free connection was found in the pool.", nil, nil)
+	redisMisses     = prometheus.NewDesc("redis_pool_misses_total", "Number of times a free connection was not found in the pool.", nil, nil)
+	redisTimeouts   = prometheus.NewDesc("redis_pool_timeouts_total", "Number of times a wait for a connection timed out.", nil, nil)
+	redisTotalConns = prometheus.NewDesc("redis_pool_total_conns", "Number of connections in the pool.", nil, nil)
+	redisIdleConns  = prometheus.NewDesc("redis_pool_idle_conns", "Number of idle connections in the pool.", nil, nil)
+	redisStaleConns = prometheus.NewDesc("redis_pool_stale_conns_total", "Number of stale connections removed from the pool.", nil, nil)
+)
+
+type dbPoolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (collector *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbAcquireCount
+	ch <- dbAcquireDuration
+	ch <- dbCanceledAcquireCount
+	ch <- dbEmptyAcquireCount
+	ch <- dbAcquiredConns
+	ch <- dbConstructingConns
+	ch <- dbIdleConns
+	ch <- dbTotalConns
+	ch <- dbMaxConns
+}
+
+func (collector *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := collector.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(dbAcquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbAcquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(dbCanceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbEmptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(dbConstructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(dbIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(dbTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(dbMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+}
+
+type redisPoolCollector struct {
+	rdb *redis.Client
+}
+
+func (collector *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHits
+	ch <- redisMisses
+	ch <- redisTimeouts
+	ch <- redisTotalConns
+	ch <- redisIdleConns
+	ch <- redisStaleConns
+}
+
+func (collector *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := collector.rdb.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisHits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMisses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisStaleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisPoolCollector(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	require.NoError(t, rdb.Ping(context.Background()).Err())
+
+	collector := &redisPoolCollector{rdb: rdb}
+	require.Equal(t, 6, testutil.CollectAndCount(collector))
+
+	expected := `
+# HELP redis_pool_total_conns Number of connections in the pool.
+# TYPE redis_pool_total_conns gauge
+redis_pool_total_conns 1
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "redis_pool_total_conns"))
+}
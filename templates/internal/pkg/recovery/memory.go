@@ -0,0 +1,26 @@
+package recovery
+
+import "sync"
+
+// MemoryReporter 将事件保存在内存中，用于测试
+type MemoryReporter struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewMemoryReporter() *MemoryReporter {
+	return &MemoryReporter{}
+}
+
+func (r *MemoryReporter) Report(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// Events 返回已上报事件的副本
+func (r *MemoryReporter) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
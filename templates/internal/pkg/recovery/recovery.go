@@ -0,0 +1,207 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/metrics"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	SourceHTTP      = "http"
+	SourceTask      = "task"
+	SourceGoroutine = "goroutine"
+)
+
+// maxFrames 最多记录的栈帧数
+const maxFrames = 64
+
+// Frame 栈帧
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Event 一次被捕获的 panic
+type Event struct {
+	ID        string
+	Time      time.Time
+	Source    string            // http / task / goroutine
+	Message   string            // panic 的值
+	Frames    []Frame           // 从 panic 发生处开始，最内层在前
+	RequestID string            // 请求 ID，可能为空
+	TraceID   string            // 可能为空
+	SpanID    string            // 可能为空
+	Tags      map[string]string // 路由、任务类型等附加信息
+}
+
+// Stack 以 runtime/debug.Stack 的格式返回调用栈
+func (event Event) Stack() string {
+	var b strings.Builder
+	for _, frame := range event.Frames {
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Reporter 将 panic 上报到错误收集服务，实现不能阻塞调用方
+type Reporter interface {
+	Report(event Event)
+}
+
+type nopReporter struct{}
+
+func (nopReporter) Report(Event) {}
+
+type reporterHolder struct{ Reporter }
+
+var reporter atomic.Pointer[reporterHolder]
+
+func init() {
+	SetReporter(nil)
+}
+
+// SetReporter 设置全局的 Reporter，nil 表示不上报
+func SetReporter(r Reporter) {
+	if r == nil {
+		r = nopReporter{}
+	}
+	reporter.Store(&reporterHolder{r})
+}
+
+// PanicError 由 Handle 返回，包装 panic 的值
+type PanicError struct {
+	Value any
+	Event Event
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", err.Value)
+}
+
+// Unwrap panic 的值是 error 时返回它
+func (err *PanicError) Unwrap() error {
+	if e, ok := err.Value.(error); ok {
+		return e
+	}
+	return nil
+}
+
+// Handle 处理 recover() 得到的值：记录带调用栈的错误日志、增加指标并上报。
+// 必须在 defer 的函数中直接调用，以便从 panic 发生处截取调用栈：
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = recovery.Handle(ctx, recovery.SourceTask, r, tags)
+//		}
+//	}()
+func Handle(ctx context.Context, source string, r any, tags map[string]string) *PanicError {
+	var frames []Frame
+	if f, ok := r.(*forwarded); ok {
+		r, frames = f.value, f.frames
+	} else {
+		frames = callers(2)
+	}
+
+	event := Event{
+		ID:      strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Time:    time.Now(),
+		Source:  source,
+		Message: fmt.Sprint(r),
+		Frames:  frames,
+		Tags:    tags,
+	}
+	if requestID, ok := logger.RequestIDFromContext(ctx); ok {
+		event.RequestID = requestID
+	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		event.TraceID = spanCtx.TraceID().String()
+		event.SpanID = spanCtx.SpanID().String()
+	}
+
+	metrics.PanicsRecoveredTotal.WithLabelValues(source).Inc()
+
+	fields := make([]zap.Field, 0, len(tags)+4)
+	fields = append(fields,
+		zap.String("source", source),
+		zap.String("panic", event.Message),
+		zap.String("event_id", event.ID),
+		zap.String("stack", event.Stack()),
+	)
+	for key, value := range tags {
+		fields = append(fields, zap.String(key, value))
+	}
+	// 调用栈已经在 stack 字段中，不再附加 zap 自己的 stacktrace
+	logger.FromContext(ctx).WithOptions(zap.AddStacktrace(zap.FatalLevel)).Error("panic recovered", fields...)
+
+	reporter.Load().Report(event)
+	return &PanicError{Value: r, Event: event}
+}
+
+// forwarded 在另一个 goroutine 中 recover 的 panic，保存了原始的调用栈
+type forwarded struct {
+	value  any
+	frames []Frame
+}
+
+// Forward 在 recover 处保存调用栈，返回值用于在另一个 goroutine 中重新 panic，
+// Handle 会使用保存的调用栈而不是重新 panic 处的调用栈
+func Forward(r any) any {
+	return &forwarded{value: r, frames: callers(2)}
+}
+
+// Value 返回 panic 的原始值，r 可以是 Forward 的返回值
+func Value(r any) any {
+	if f, ok := r.(*forwarded); ok {
+		return f.value
+	}
+	return r
+}
+
+// Go 在新的 goroutine 中运行 fn，panic 会被记录并上报，不会导致进程退出
+func Go(ctx context.Context, name string, fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				Handle(ctx, SourceGoroutine, r, map[string]string{"goroutine": name})
+			}
+		}()
+		fn()
+	}()
+}
+
+// callers 返回调用栈，去掉 recover 所在的 defer 函数和 runtime 中处理 panic 的栈帧
+func callers(skip int) []Frame {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var result []Frame
+	for {
+		frame, more := frames.Next()
+		result = append(result, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if frame.Function == "runtime.gopanic" {
+			result = result[:0]
+		}
+		if !more {
+			break
+		}
+	}
+	return result
+}
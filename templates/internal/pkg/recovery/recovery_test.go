@@ -0,0 +1,100 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/config"
+	"github.com/a1ostudio/nova/internal/logger"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.NewLogger(config.Config{Env: config.Dev})
+	os.Exit(m.Run())
+}
+
+func useMemoryReporter(t *testing.T) *MemoryReporter {
+	r := NewMemoryReporter()
+	SetReporter(r)
+	t.Cleanup(func() { SetReporter(nil) })
+	return r
+}
+
+func panicky() {
+	panic(errors.New("boom"))
+}
+
+func TestHandle(t *testing.T) {
+	reporter := useMemoryReporter(t)
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = Handle(ctx, SourceTask, r, map[string]string{"task_type": "email:send"})
+			}
+		}()
+		panicky()
+		return nil
+	}()
+
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	require.EqualError(t, errors.Unwrap(err), "boom")
+
+	events := reporter.Events()
+	require.Len(t, events, 1)
+	event := events[0]
+	require.Equal(t, SourceTask, event.Source)
+	require.Equal(t, "boom", event.Message)
+	require.Equal(t, "req-1", event.RequestID)
+	require.Equal(t, "email:send", event.Tags["task_type"])
+	require.Len(t, event.ID, 32)
+	// 调用栈从 panic 发生处开始
+	require.True(t, strings.HasSuffix(event.Frames[0].Function, "recovery.panicky"), event.Stack())
+}
+
+func TestGo(t *testing.T) {
+	reporter := useMemoryReporter(t)
+
+	Go(context.Background(), "worker", panicky)
+
+	require.Eventually(t, func() bool { return len(reporter.Events()) == 1 }, time.Second, 10*time.Millisecond)
+	event := reporter.Events()[0]
+	require.Equal(t, SourceGoroutine, event.Source)
+	require.Equal(t, "worker", event.Tags["goroutine"])
+}
+
+func TestForward(t *testing.T) {
+	reporter := useMemoryReporter(t)
+
+	forwarded := make(chan any, 1)
+	go func() {
+		defer func() {
+			forwarded <- Forward(recover())
+		}()
+		panicky()
+	}()
+	r := <-forwarded
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				Handle(context.Background(), SourceHTTP, r, nil)
+			}
+		}()
+		panic(r)
+	}()
+
+	events := reporter.Events()
+	require.Len(t, events, 1)
+	require.Equal(t, "boom", events[0].Message)
+	// 使用原始 goroutine 中的调用栈
+	require.True(t, strings.HasSuffix(events[0].Frames[0].Function, "recovery.panicky"), events[0].Stack())
+}
@@ -0,0 +1,233 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+type SentryOptions struct {
+	DSN         string        // https://<key>@<host>/<project_id>
+	Environment string        // 环境名称
+	Release     string        // 版本号
+	QueueSize   int           // 待发送事件的缓冲大小，默认 100，满了之后丢弃新事件
+	Timeout     time.Duration // 单次请求超时，默认 5s
+	HTTPClient  *http.Client
+}
+
+func (opts SentryOptions) withDefaults() SentryOptions {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 100
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{}
+	}
+	return opts
+}
+
+// SentryReporter 通过 Sentry 的 store 接口上报事件，兼容 Sentry 和 GlitchTip 等实现。
+// 事件在后台 goroutine 中发送，Report 不会阻塞
+type SentryReporter struct {
+	opts       SentryOptions
+	endpoint   string
+	auth       string
+	serverName string
+
+	mu     sync.RWMutex
+	closed bool
+	events chan Event
+	done   chan struct{}
+}
+
+func NewSentryReporter(opts SentryOptions) (*SentryReporter, error) {
+	opts = opts.withDefaults()
+
+	endpoint, key, err := parseDSN(opts.DSN)
+	if err != nil {
+		return nil, err
+	}
+	serverName, _ := os.Hostname()
+
+	r := &SentryReporter{
+		opts:       opts,
+		endpoint:   endpoint,
+		auth:       fmt.Sprintf("Sentry sentry_version=7, sentry_client=nova/1.0, sentry_key=%s", key),
+		serverName: serverName,
+		events:     make(chan Event, opts.QueueSize),
+		done:       make(chan struct{}),
+	}
+	go r.run()
+	return r, nil
+}
+
+// parseDSN 返回 store 接口地址和公钥
+func parseDSN(dsn string) (endpoint, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("recovery: invalid sentry dsn: %w", err)
+	}
+	key = u.User.Username()
+	projectID := path.Base(u.Path)
+	if u.Scheme == "" || u.Host == "" || key == "" || projectID == "" || projectID == "/" || projectID == "." {
+		return "", "", errors.New("recovery: invalid sentry dsn")
+	}
+	prefix := strings.TrimSuffix(path.Dir(u.Path), "/")
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID), key, nil
+}
+
+func (r *SentryReporter) Report(event Event) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+
+	select {
+	case r.events <- event:
+	default:
+		logger.L().Named("sentry").Warn("sentry queue is full, event dropped", zap.String("event_id", event.ID))
+	}
+}
+
+// Close 停止接收新事件，并等待缓冲中的事件发送完成
+func (r *SentryReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.events)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *SentryReporter) run() {
+	defer close(r.done)
+	for event := range r.events {
+		if err := r.send(event); err != nil {
+			logger.L().Named("sentry").Warn("failed to send event to sentry", zap.String("event_id", event.ID), zap.Error(err))
+		}
+	}
+}
+
+func (r *SentryReporter) send(event Event) error {
+	body, err := json.Marshal(r.payload(event))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	res, err := r.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Contexts    map[string]any    `json:"contexts,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string           `json:"type"`
+	Value      string           `json:"value"`
+	Stacktrace sentryStacktrace `json:"stacktrace"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+func (r *SentryReporter) payload(event Event) sentryEvent {
+	tags := make(map[string]string, len(event.Tags)+2)
+	for key, value := range event.Tags {
+		tags[key] = value
+	}
+	tags["source"] = event.Source
+	if event.RequestID != "" {
+		tags["request_id"] = event.RequestID
+	}
+
+	// Sentry 要求栈帧从最外层开始
+	frames := make([]sentryFrame, len(event.Frames))
+	for i, frame := range event.Frames {
+		frames[len(frames)-1-i] = sentryFrame{Function: frame.Function, AbsPath: frame.File, Lineno: frame.Line}
+	}
+
+	payload := sentryEvent{
+		EventID:     event.ID,
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Level:       "fatal",
+		Platform:    "go",
+		Logger:      event.Source,
+		ServerName:  r.serverName,
+		Environment: r.opts.Environment,
+		Release:     r.opts.Release,
+		Tags:        tags,
+		Exception: sentryExceptions{Values: []sentryException{{
+			Type:       "panic",
+			Value:      logger.Redact(event.Message), // 发送到外部服务前脱敏
+			Stacktrace: sentryStacktrace{Frames: frames},
+		}}},
+	}
+	if event.TraceID != "" {
+		payload.Contexts = map[string]any{
+			"trace": map[string]string{"trace_id": event.TraceID, "span_id": event.SpanID},
+		}
+	}
+	return payload
+}
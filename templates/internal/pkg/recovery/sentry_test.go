@@ -0,0 +1,86 @@
+package recovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSN(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dsn      string
+		endpoint string
+		key      string
+		wantErr  bool
+	}{
+		{name: "OK", dsn: "https://abc@sentry.example.com/42", endpoint: "https://sentry.example.com/api/42/store/", key: "abc"},
+		{name: "PathPrefix", dsn: "https://abc@example.com/sentry/42", endpoint: "https://example.com/sentry/api/42/store/", key: "abc"},
+		{name: "NoKey", dsn: "https://sentry.example.com/42", wantErr: true},
+		{name: "NoProject", dsn: "https://abc@sentry.example.com", wantErr: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint, key, err := parseDSN(tc.dsn)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.endpoint, endpoint)
+			require.Equal(t, tc.key, key)
+		})
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	received := make(chan sentryEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/42/store/", r.URL.Path)
+		require.Contains(t, r.Header.Get("X-Sentry-Auth"), "sentry_key=abc")
+
+		var event sentryEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer srv.Close()
+
+	reporter, err := NewSentryReporter(SentryOptions{
+		DSN:         strings.Replace(srv.URL, "http://", "http://abc@", 1) + "/42",
+		Environment: "test",
+	})
+	require.NoError(t, err)
+
+	reporter.Report(Event{
+		ID:        "0123456789abcdef0123456789abcdef",
+		Time:      time.Now(),
+		Source:    SourceHTTP,
+		Message:   "boom: user 13812345678",
+		Frames:    []Frame{{Function: "inner", File: "a.go", Line: 1}, {Function: "outer", File: "b.go", Line: 2}},
+		RequestID: "req-1",
+		TraceID:   "4bf92f3577b34da6a3ce929d0e0e4736",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, reporter.Close(ctx))
+	// 关闭后的事件直接丢弃
+	reporter.Report(Event{})
+
+	event := <-received
+	require.Equal(t, "0123456789abcdef0123456789abcdef", event.EventID)
+	require.Equal(t, "test", event.Environment)
+	require.Equal(t, "req-1", event.Tags["request_id"])
+	require.Equal(t, "boom: user 138****5678", event.Exception.Values[0].Value)
+	require.Equal(t, "outer", event.Exception.Values[0].Stacktrace.Frames[0].Function)
+	require.NotNil(t, event.Contexts["trace"])
+}
@@ -10,10 +10,11 @@ import (
 
 // AcquireLock tries to create a lock with a random owner value. It returns
 // (owner, true, nil) when the lock is acquired. If not acquired returns
-// ("", false, nil). Any redis error is returned as err.
+// ("", false, nil). Any redis error is returned as err. Like Locker, the
+// lock is stored under "{key}".
 func AcquireLock(ctx context.Context, rdb *redis.Client, key string, ttl time.Duration) (string, bool, error) {
 	owner := uuid.NewString()
-	ok, err := rdb.SetNX(ctx, key, owner, ttl).Result()
+	ok, err := rdb.SetNX(ctx, lockKey(key), owner, ttl).Result()
 	if err != nil {
 		return "", false, err
 	}
@@ -23,17 +24,27 @@ func AcquireLock(ctx context.Context, rdb *redis.Client, key string, ttl time.Du
 	return owner, true, nil
 }
 
+// AcquireFencedLock works like AcquireLock and additionally returns a
+// fencing token that increases with every acquisition of key.
+func AcquireFencedLock(ctx context.Context, rdb *redis.Client, key string, ttl time.Duration) (string, int64, bool, error) {
+	owner := uuid.NewString()
+	token, err := obtain(ctx, rdb, key, owner, ttl)
+	if err != nil {
+		return "", 0, false, err
+	}
+	if token == 0 {
+		return "", 0, false, nil
+	}
+	return owner, token, true, nil
+}
+
 // ReleaseLock releases the lock only if the owner matches. It uses a Lua
 // script to ensure atomicity. Returns true if the lock was released.
 func ReleaseLock(ctx context.Context, rdb *redis.Client, key string, owner string) (bool, error) {
 	// Lua script: if value matches then del and return 1 else return 0
-	const lua = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
-	res, err := rdb.Eval(ctx, lua, []string{key}, owner).Result()
+	res, err := releaseScript.Run(ctx, rdb, []string{lockKey(key)}, owner).Int64()
 	if err != nil {
 		return false, err
 	}
-	if v, ok := res.(int64); ok && v > 0 {
-		return true, nil
-	}
-	return false, nil
+	return res > 0, nil
 }
@@ -0,0 +1,260 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotAcquired is returned when the lock could not be obtained within
+	// MaxWaitTime.
+	ErrNotAcquired = errors.New("redislock: lock not acquired")
+	// ErrNotHeld is returned when the lock has expired or is owned by
+	// someone else.
+	ErrNotHeld = errors.New("redislock: lock not held")
+)
+
+var (
+	// obtainScript sets the lock and bumps the fencing counter atomically.
+	// It returns the new fencing token, or 0 if the lock is taken.
+	obtainScript  = redis.NewScript(`if redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then return redis.call("incr", KEYS[2]) else return 0 end`)
+	releaseScript = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+	extendScript  = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`)
+)
+
+// Options controls lock TTL and how long Obtain waits for a busy lock.
+type Options struct {
+	TTL             time.Duration // lock TTL, default 2s
+	MaxWaitTime     time.Duration // total time to wait for the lock, default 1s
+	InitialWaitTime time.Duration // first backoff interval, default 50ms
+	MaxSingleWait   time.Duration // cap of a single backoff interval, default 200ms
+	AutoRenew       bool          // WithLock keeps the lock alive with a Watchdog
+}
+
+func (opts Options) withDefaults() Options {
+	if opts.TTL <= 0 {
+		opts.TTL = 2 * time.Second
+	}
+	if opts.MaxWaitTime < 0 {
+		opts.MaxWaitTime = 0
+	}
+	if opts.InitialWaitTime <= 0 {
+		opts.InitialWaitTime = 50 * time.Millisecond
+	}
+	if opts.MaxSingleWait <= 0 {
+		opts.MaxSingleWait = 200 * time.Millisecond
+	}
+	if opts.InitialWaitTime > opts.MaxSingleWait {
+		opts.InitialWaitTime = opts.MaxSingleWait
+	}
+	return opts
+}
+
+// Locker obtains locks, retrying with exponential backoff and jitter while
+// the lock is held by someone else.
+type Locker struct {
+	rdb  *redis.Client
+	opts Options
+}
+
+// NewLocker creates a Locker. Zero values in opts fall back to the defaults
+// used by config.LoadConfig.
+func NewLocker(rdb *redis.Client, opts Options) *Locker {
+	return &Locker{
+		rdb:  rdb,
+		opts: opts.withDefaults(),
+	}
+}
+
+// Obtain blocks until the lock is acquired, MaxWaitTime elapses or ctx is
+// done. It returns ErrNotAcquired when the wait time is exhausted.
+func (locker *Locker) Obtain(ctx context.Context, key string) (*Lock, error) {
+	owner := uuid.NewString()
+	deadline := time.Now().Add(locker.opts.MaxWaitTime)
+	wait := locker.opts.InitialWaitTime
+
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		token, err := obtain(ctx, locker.rdb, key, owner, locker.opts.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("redislock: obtain %q: %w", key, err)
+		}
+		if token > 0 {
+			return &Lock{rdb: locker.rdb, key: key, owner: owner, token: token, ttl: locker.opts.TTL}, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrNotAcquired
+		}
+
+		sleep := min(jitter(wait), remaining)
+		if timer == nil {
+			timer = time.NewTimer(sleep)
+		} else {
+			timer.Reset(sleep)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		wait = min(wait*2, locker.opts.MaxSingleWait)
+	}
+}
+
+// WithLock runs fn while holding the lock for key. The lock is released
+// when fn returns, even if fn fails. With AutoRenew the lock is renewed
+// until fn returns, and the ctx passed to fn is cancelled with ErrLockLost
+// if the lock is lost in the meantime. fn can read the fencing token with
+// FencingToken(ctx).
+func (locker *Locker) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) (err error) {
+	lock, err := locker.Obtain(ctx, key)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, lockContextKey{}, lock)
+	defer func() {
+		releaseErr := lock.Release(context.WithoutCancel(ctx))
+		if err == nil {
+			err = releaseErr
+		}
+	}()
+
+	if locker.opts.AutoRenew {
+		lockCtx := lock.StartWatchdog(ctx).Context()
+		err = fn(lockCtx)
+		if err == nil && errors.Is(context.Cause(lockCtx), ErrLockLost) {
+			err = ErrLockLost
+		}
+		return err
+	}
+
+	return fn(ctx)
+}
+
+// obtain runs obtainScript and returns the fencing token, 0 if the lock is
+// held by someone else.
+func obtain(ctx context.Context, rdb *redis.Client, key, owner string, ttl time.Duration) (int64, error) {
+	return obtainScript.Run(ctx, rdb, []string{lockKey(key), fenceKey(key)}, owner, ttl.Milliseconds()).Int64()
+}
+
+// lockKey is the redis key of the lock for key. The hash tag keeps the lock
+// and its fencing counter in the same Redis Cluster slot, otherwise
+// obtainScript fails with CROSSSLOT.
+func lockKey(key string) string {
+	return "{" + key + "}"
+}
+
+// fenceKey is the counter holding the last fencing token issued for key.
+// It never expires, so tokens keep increasing across lock lifetimes.
+func fenceKey(key string) string {
+	return lockKey(key) + ":fence"
+}
+
+type lockContextKey struct{}
+
+// FencingToken returns the fencing token of the lock held by WithLock.
+func FencingToken(ctx context.Context) (int64, bool) {
+	lock, ok := ctx.Value(lockContextKey{}).(*Lock)
+	if !ok {
+		return 0, false
+	}
+	return lock.token, true
+}
+
+// jitter returns a random duration in [d/2, d].
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
+
+// Lock is a handle to an acquired lock.
+type Lock struct {
+	rdb      *redis.Client
+	key      string
+	owner    string
+	token    int64
+	ttl      time.Duration
+	watchdog *Watchdog
+}
+
+// Key returns the key passed to Obtain. The lock is stored in redis under
+// "{key}", see lockKey.
+func (lock *Lock) Key() string {
+	return lock.key
+}
+
+// Owner returns the random value identifying this holder.
+func (lock *Lock) Owner() string {
+	return lock.owner
+}
+
+// Token returns the fencing token issued with this lock. Tokens for the same
+// key only go up, so downstream stores can reject writes from a holder
+// whose lock has since been taken over.
+func (lock *Lock) Token() int64 {
+	return lock.token
+}
+
+// StartWatchdog keeps the lock alive until it is released or ctx is done.
+// Calling it again returns the running watchdog.
+func (lock *Lock) StartWatchdog(ctx context.Context) *Watchdog {
+	if lock.watchdog == nil {
+		lock.watchdog = StartWatchdog(ctx, lock.rdb, lock.key, lock.owner, lock.ttl)
+	}
+	return lock.watchdog
+}
+
+// Release stops the watchdog, if any, and deletes the lock if it is still
+// owned by this holder. It returns ErrNotHeld if the lock has already
+// expired or changed hands.
+func (lock *Lock) Release(ctx context.Context) error {
+	if lock.watchdog != nil {
+		lock.watchdog.Stop()
+	}
+
+	res, err := releaseScript.Run(ctx, lock.rdb, []string{lockKey(lock.key)}, lock.owner).Int64()
+	if err != nil {
+		return fmt.Errorf("redislock: release %q: %w", lock.key, err)
+	}
+	if res == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Extend resets the TTL of the lock to ttl. A non-positive ttl reuses the
+// TTL the lock was obtained with. It returns ErrNotHeld if the lock has
+// already expired or changed hands.
+func (lock *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = lock.ttl
+	}
+	res, err := extendScript.Run(ctx, lock.rdb, []string{lockKey(lock.key)}, lock.owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("redislock: extend %q: %w", lock.key, err)
+	}
+	if res == 0 {
+		return ErrNotHeld
+	}
+	lock.ttl = ttl
+	return nil
+}
@@ -0,0 +1,136 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocker(t *testing.T, opts Options) (*Locker, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return NewLocker(rdb, opts), mr
+}
+
+func TestLockerObtainAndRelease(t *testing.T) {
+	locker, mr := newTestLocker(t, Options{TTL: time.Second, MaxWaitTime: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	lock, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+	require.NotEmpty(t, lock.Owner())
+	require.Equal(t, lock.Owner(), mustGet(t, mr, "{lock:test}"))
+
+	start := time.Now()
+	_, err = locker.Obtain(ctx, "lock:test")
+	require.ErrorIs(t, err, ErrNotAcquired)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	require.NoError(t, lock.Release(ctx))
+	require.False(t, mr.Exists("{lock:test}"))
+	require.ErrorIs(t, lock.Release(ctx), ErrNotHeld)
+
+	lock, err = locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+	require.NoError(t, lock.Release(ctx))
+}
+
+func TestLockerObtainWaitsForRelease(t *testing.T) {
+	locker, _ := newTestLocker(t, Options{TTL: time.Second, MaxWaitTime: time.Second})
+	ctx := context.Background()
+
+	lock, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = lock.Release(ctx)
+	}()
+
+	next, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+	require.NotEqual(t, lock.Owner(), next.Owner())
+}
+
+func TestLockerObtainContextCanceled(t *testing.T) {
+	locker, _ := newTestLocker(t, Options{TTL: time.Second, MaxWaitTime: time.Minute})
+
+	_, err := locker.Obtain(context.Background(), "lock:test")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = locker.Obtain(ctx, "lock:test")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLockExtend(t *testing.T) {
+	locker, mr := newTestLocker(t, Options{TTL: time.Second})
+	ctx := context.Background()
+
+	lock, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+
+	require.NoError(t, lock.Extend(ctx, 10*time.Second))
+	require.Equal(t, 10*time.Second, mr.TTL("{lock:test}"))
+
+	mr.FastForward(11 * time.Second)
+	require.ErrorIs(t, lock.Extend(ctx, 0), ErrNotHeld)
+}
+
+func TestLockerWithLock(t *testing.T) {
+	locker, mr := newTestLocker(t, Options{TTL: time.Second})
+	ctx := context.Background()
+
+	errTest := errors.New("test error")
+	err := locker.WithLock(ctx, "lock:test", func(ctx context.Context) error {
+		require.True(t, mr.Exists("{lock:test}"))
+		return errTest
+	})
+	require.ErrorIs(t, err, errTest)
+	require.False(t, mr.Exists("{lock:test}"))
+}
+
+func mustGet(t *testing.T, mr *miniredis.Miniredis, key string) string {
+	t.Helper()
+	value, err := mr.Get(key)
+	require.NoError(t, err)
+	return value
+}
+
+func TestLockerFencingToken(t *testing.T) {
+	locker, mr := newTestLocker(t, Options{TTL: time.Second})
+	ctx := context.Background()
+
+	first, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), first.Token())
+
+	// 锁过期后被他人获取，新的 token 必须更大
+	mr.FastForward(2 * time.Second)
+	second, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+	require.Greater(t, second.Token(), first.Token())
+	require.NoError(t, second.Release(ctx))
+
+	err = locker.WithLock(ctx, "lock:test", func(ctx context.Context) error {
+		token, ok := FencingToken(ctx)
+		require.True(t, ok)
+		require.Greater(t, token, second.Token())
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, token, ok, err := AcquireFencedLock(ctx, locker.rdb, "lock:test", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(4), token)
+	// 锁和 fencing 计数器使用同一个 hash tag，在 Redis Cluster 中位于同一个 slot
+	require.Equal(t, "4", mustGet(t, mr, "{lock:test}:fence"))
+}
@@ -0,0 +1,93 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockLost is the cancellation cause of Watchdog.Context when the lock
+// expired or was taken over before it could be renewed.
+var ErrLockLost = errors.New("redislock: lock lost")
+
+// Watchdog keeps extending a lock every ttl/3 while the holder is alive.
+// It stops when Stop is called, when the parent context is done, or when
+// the lock is found to be owned by someone else.
+type Watchdog struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	lost     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartWatchdog starts renewing key for owner in the background. It can be
+// used together with AcquireLock for locks that are not managed by a Locker.
+func StartWatchdog(ctx context.Context, rdb *redis.Client, key, owner string, ttl time.Duration) *Watchdog {
+	wctx, cancel := context.WithCancelCause(ctx)
+	w := &Watchdog{
+		ctx:    wctx,
+		cancel: cancel,
+		lost:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run(rdb, lockKey(key), owner, ttl)
+	return w
+}
+
+func (w *Watchdog) run(rdb *redis.Client, key, owner string, ttl time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(max(ttl/3, time.Millisecond))
+	defer ticker.Stop()
+
+	lastExtended := time.Now()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		res, err := extendScript.Run(w.ctx, rdb, []string{key}, owner, ttl.Milliseconds()).Int64()
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return
+			}
+			// transient redis error: keep retrying while the last TTL may still hold
+			if time.Since(lastExtended) < ttl {
+				continue
+			}
+		} else if res > 0 {
+			lastExtended = time.Now()
+			continue
+		}
+
+		close(w.lost)
+		w.cancel(ErrLockLost)
+		return
+	}
+}
+
+// Lost is closed when the watchdog detects that the lock is no longer held.
+func (w *Watchdog) Lost() <-chan struct{} {
+	return w.lost
+}
+
+// Context is cancelled when the lock is lost (with cause ErrLockLost), when
+// the watchdog is stopped, or when the parent context is done.
+func (w *Watchdog) Context() context.Context {
+	return w.ctx
+}
+
+// Stop stops renewing the lock and waits for the background goroutine to
+// exit. It does not release the lock.
+func (w *Watchdog) Stop() {
+	w.stopOnce.Do(func() {
+		w.cancel(context.Canceled)
+	})
+	<-w.done
+}
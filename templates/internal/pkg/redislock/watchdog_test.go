@@ -0,0 +1,81 @@
+package redislock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdogRenewsLock(t *testing.T) {
+	locker, mr := newTestLocker(t, Options{TTL: 90 * time.Millisecond})
+	ctx := context.Background()
+
+	lock, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+	w := lock.StartWatchdog(ctx)
+
+	// miniredis only expires keys on FastForward, so each renewal resets the TTL
+	for range 3 {
+		mr.FastForward(60 * time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		require.True(t, mr.Exists("{lock:test}"))
+	}
+
+	require.NoError(t, lock.Release(ctx))
+	require.ErrorIs(t, context.Cause(w.Context()), context.Canceled)
+	select {
+	case <-w.Lost():
+		t.Fatal("lock should not be reported as lost")
+	default:
+	}
+}
+
+func TestWatchdogReportsLostLock(t *testing.T) {
+	locker, mr := newTestLocker(t, Options{TTL: 60 * time.Millisecond})
+	ctx := context.Background()
+
+	lock, err := locker.Obtain(ctx, "lock:test")
+	require.NoError(t, err)
+	w := lock.StartWatchdog(ctx)
+
+	mr.Set("{lock:test}", "someone-else")
+
+	select {
+	case <-w.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not report the lost lock")
+	}
+	require.ErrorIs(t, context.Cause(w.Context()), ErrLockLost)
+	require.ErrorIs(t, lock.Release(ctx), ErrNotHeld)
+}
+
+func TestWatchdogStopsWithContext(t *testing.T) {
+	locker, _ := newTestLocker(t, Options{TTL: 60 * time.Millisecond})
+
+	lock, err := locker.Obtain(context.Background(), "lock:test")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := lock.StartWatchdog(ctx)
+	cancel()
+
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not stop")
+	}
+	require.ErrorIs(t, w.Context().Err(), context.Canceled)
+}
+
+func TestLockerWithLockAutoRenew(t *testing.T) {
+	locker, mr := newTestLocker(t, Options{TTL: 60 * time.Millisecond, AutoRenew: true})
+
+	err := locker.WithLock(context.Background(), "lock:test", func(ctx context.Context) error {
+		mr.Del("{lock:test}")
+		<-ctx.Done()
+		return nil
+	})
+	require.ErrorIs(t, err, ErrLockLost)
+}
@@ -0,0 +1,135 @@
+package resp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type cacheOptions struct {
+	etag         string
+	lastModified time.Time
+	cacheControl string
+}
+
+type CacheOption func(*cacheOptions)
+
+// WithVersion 使用资源的版本号作为 ETag，不再计算响应体的哈希
+func WithVersion(version any) CacheOption {
+	return func(o *cacheOptions) {
+		o.etag = ETag(version)
+	}
+}
+
+// WithLastModified 设置 Last-Modified，并支持 If-Modified-Since
+func WithLastModified(t time.Time) CacheOption {
+	return func(o *cacheOptions) {
+		o.lastModified = t
+	}
+}
+
+// WithCacheControl 覆盖默认的 Cache-Control: private, no-cache
+func WithCacheControl(value string) CacheOption {
+	return func(o *cacheOptions) {
+		o.cacheControl = value
+	}
+}
+
+// ETag 将资源的版本号转换为强 ETag，写请求用它和 If-Match 比较
+func ETag(version any) string {
+	return `"` + fmt.Sprint(version) + `"`
+}
+
+// SuccessWithCache 与 Success 相同，同时返回 ETag。
+//
+// 未指定 WithVersion 时 ETag 为响应体的哈希。GET 和 HEAD 请求的 If-None-Match 匹配时返回 304，
+// 没有 If-None-Match 时按 If-Modified-Since 判断
+func SuccessWithCache[T any](c *gin.Context, data T, options ...CacheOption) {
+	opts := cacheOptions{cacheControl: "private, no-cache"}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	body, err := json.Marshal(Result[T]{
+		Code:    0,
+		Message: "success",
+		Data:    data,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		ServerError(c)
+		return
+	}
+
+	etag := opts.etag
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	}
+
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", opts.cacheControl)
+	if !opts.lastModified.IsZero() {
+		header.Set("Last-Modified", opts.lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, opts.lastModified) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		// If-None-Match 使用弱比较，Compress 会把强 ETag 改为弱 ETag
+		return matchETag(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		// HTTP 日期只精确到秒
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// CheckIfMatch 校验写请求的 If-Match，etag 为资源当前的 ETag，通常是 ETag(version)。
+// 未携带 If-Match 时直接通过；不匹配时返回 412 并返回 false，调用方应直接返回
+func CheckIfMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+	// 经过 Compress 的响应 ETag 带有 W/ 前缀，客户端原样回传，因此这里同样忽略 W/
+	if etag != "" && matchETag(header, etag) {
+		return true
+	}
+	PreconditionFailedError(c)
+	return false
+}
+
+// matchETag 判断逗号分隔的 ETag 列表中是否包含 etag，忽略 W/ 前缀，* 匹配任意 ETag
+func matchETag(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
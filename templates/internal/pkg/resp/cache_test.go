@@ -0,0 +1,132 @@
+package resp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheServer() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	server.GET("/hash", func(c *gin.Context) {
+		SuccessWithCache(c, gin.H{"name": "nova"})
+	})
+	server.GET("/version", func(c *gin.Context) {
+		SuccessWithCache(c, gin.H{"name": "nova"}, WithVersion(3), WithLastModified(updatedAt), WithCacheControl("private, max-age=60"))
+	})
+	server.PUT("/version", func(c *gin.Context) {
+		if !CheckIfMatch(c, ETag(3)) {
+			return
+		}
+		Success(c, "updated")
+	})
+	return server
+}
+
+func TestSuccessWithCache(t *testing.T) {
+	server := newCacheServer()
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/hash", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	hashETag := recorder.Header().Get("ETag")
+	require.Regexp(t, `^"[A-Za-z0-9_-]+"$`, hashETag)
+	require.Equal(t, "private, no-cache", recorder.Header().Get("Cache-Control"))
+	require.JSONEq(t, `{"code":0,"message":"success","data":{"name":"nova"}}`, recorder.Body.String())
+
+	testCases := []struct {
+		name       string
+		path       string
+		header     map[string]string
+		wantStatus int
+	}{
+		{name: "HashMatch", path: "/hash", header: map[string]string{"If-None-Match": hashETag}, wantStatus: http.StatusNotModified},
+		{name: "HashMismatch", path: "/hash", header: map[string]string{"If-None-Match": `"other"`}, wantStatus: http.StatusOK},
+		{name: "WeakMatch", path: "/version", header: map[string]string{"If-None-Match": `"1", W/"3"`}, wantStatus: http.StatusNotModified},
+		{name: "Wildcard", path: "/version", header: map[string]string{"If-None-Match": "*"}, wantStatus: http.StatusNotModified},
+		{name: "NotModifiedSince", path: "/version", header: map[string]string{"If-Modified-Since": "Fri, 02 Jan 2026 03:04:05 GMT"}, wantStatus: http.StatusNotModified},
+		{name: "ModifiedSince", path: "/version", header: map[string]string{"If-Modified-Since": "Fri, 02 Jan 2026 03:04:04 GMT"}, wantStatus: http.StatusOK},
+		{
+			// If-None-Match 优先于 If-Modified-Since
+			name:       "NoneMatchWins",
+			path:       "/version",
+			header:     map[string]string{"If-None-Match": `"2"`, "If-Modified-Since": "Fri, 02 Jan 2026 03:04:05 GMT"},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for key, value := range tc.header {
+				request.Header.Set(key, value)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.wantStatus, recorder.Code)
+			require.NotEmpty(t, recorder.Header().Get("ETag"))
+			if tc.wantStatus == http.StatusNotModified {
+				require.Empty(t, recorder.Body.String())
+			}
+			if tc.path == "/version" {
+				require.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+				require.Equal(t, "private, max-age=60", recorder.Header().Get("Cache-Control"))
+				require.Equal(t, "Fri, 02 Jan 2026 03:04:05 GMT", recorder.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	server := newCacheServer()
+
+	testCases := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{name: "NoHeader", wantStatus: http.StatusOK},
+		{name: "Match", ifMatch: `"3"`, wantStatus: http.StatusOK},
+		{name: "WeakMatch", ifMatch: `W/"3"`, wantStatus: http.StatusOK},
+		{name: "Wildcard", ifMatch: "*", wantStatus: http.StatusOK},
+		{name: "Mismatch", ifMatch: `"2"`, wantStatus: http.StatusPreconditionFailed},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPut, "/version", nil)
+			if tc.ifMatch != "" {
+				request.Header.Set("If-Match", tc.ifMatch)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.wantStatus, recorder.Code)
+			if tc.wantStatus == http.StatusPreconditionFailed {
+				require.JSONEq(t, `{"code":412,"message":"precondition failed","data":null}`, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestAsPreconditionFailed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	HandleErrors(c, ErrUserHasBeenModified, []ErrorMapping{
+		{Target: ErrUserHasBeenModified, Action: AsPreconditionFailed},
+	})
+
+	require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	require.Contains(t, recorder.Body.String(), ErrUserHasBeenModified.Message)
+}
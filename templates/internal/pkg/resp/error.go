@@ -19,9 +19,13 @@ var (
 	ErrNotFound                    = AppError{404, "the requested resource could not be found"} // 资源未找到
 	ErrMethodNotAllowed            = AppError{405, ""}                                          // 不支持的请求方法
 	ErrConflict                    = AppError{409, "conflict"}                                  // 冲突
+	ErrPreconditionFailed          = AppError{412, "precondition failed"}                       // If-Match 与资源当前版本不一致
+	ErrRequestEntityTooLarge       = AppError{413, "request entity too large"}                  // 请求体过大
+	ErrUnsupportedMediaType        = AppError{415, "unsupported media type"}                    // 不支持的请求体编码
 	ErrStatusUnprocessableEntity   = AppError{422, "validation Error"}                          // 参数校验失败
 	ErrTooManyRequests             = AppError{429, "too Many Requests"}                         // 请求过于频繁
 	ErrServerError                 = AppError{500, "internal Server Error"}                     // 服务器内部错误
+	ErrServiceUnavailable          = AppError{503, "service Unavailable"}                       // 服务不可用
 	ErrGatewayTimeout              = AppError{504, "request Timeout"}                           // 请求超时
 	ErrUsernameAlreadyExists       = AppError{1001, "username already exists"}                  // 用户名已存在
 	ErrIncorrectUsernameOrPassword = AppError{1002, "incorrect username or password"}           // 用户名或密码错误
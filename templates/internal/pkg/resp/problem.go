@@ -0,0 +1,199 @@
+package resp
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/a1ostudio/nova/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType RFC 9457 problem details 的媒体类型
+const ProblemContentType = "application/problem+json"
+
+// ErrorFormat 错误响应的格式
+type ErrorFormat string
+
+const (
+	ErrorFormatResult  ErrorFormat = "result"  // Result 信封，默认
+	ErrorFormatProblem ErrorFormat = "problem" // RFC 9457 problem+json
+)
+
+// Problem RFC 9457 problem details，code 和 errors 为扩展字段
+type Problem struct {
+	Type     string `json:"type" example:"https://a1o.studio/problems/user-not-found"` // 错误类型的 URI，未注册的错误码为 about:blank
+	Title    string `json:"title" example:"Not Found"`                                 // HTTP 状态码对应的描述
+	Status   int    `json:"status" example:"404"`                                      // HTTP 状态码
+	Detail   string `json:"detail,omitempty" example:"user not found"`                 // 错误信息
+	Instance string `json:"instance,omitempty"`                                        // 请求 ID
+	Code     int    `json:"code" example:"1006"`                                       // 业务错误码，与 Result.Code 相同
+	Errors   any    `json:"errors,omitempty"`                                          // 参数校验失败时为 []validation.ValidationError
+} //	@name	Problem
+
+var problems = struct {
+	sync.RWMutex
+	format   ErrorFormat
+	typeBase string
+	types    map[int]string
+}{
+	format: ErrorFormatResult,
+	types: map[int]string{
+		ErrBadRequest.Code:                  "bad-request",
+		ErrUnauthorized.Code:                "unauthorized",
+		ErrForbidden.Code:                   "forbidden",
+		ErrNotFound.Code:                    "not-found",
+		ErrMethodNotAllowed.Code:            "method-not-allowed",
+		ErrConflict.Code:                    "conflict",
+		ErrPreconditionFailed.Code:          "precondition-failed",
+		ErrRequestEntityTooLarge.Code:       "request-entity-too-large",
+		ErrUnsupportedMediaType.Code:        "unsupported-media-type",
+		ErrStatusUnprocessableEntity.Code:   "validation-failed",
+		ErrTooManyRequests.Code:             "too-many-requests",
+		ErrServerError.Code:                 "internal-server-error",
+		ErrServiceUnavailable.Code:          "service-unavailable",
+		ErrGatewayTimeout.Code:              "gateway-timeout",
+		ErrUsernameAlreadyExists.Code:       "username-already-exists",
+		ErrIncorrectUsernameOrPassword.Code: "incorrect-username-or-password",
+		ErrUserAlreadyHasFamily.Code:        "user-already-has-family",
+		ErrUserHasBeenModified.Code:         "user-has-been-modified",
+		ErrInvalidOriginalPassword.Code:     "invalid-original-password",
+		ErrUserNotFound.Code:                "user-not-found",
+		ErrPhoneNumberAlreadyExists.Code:    "phone-number-already-exists",
+		ErrSessionBlocked.Code:              "session-blocked",
+		ErrSessionUserIDMismatch.Code:       "session-user-mismatch",
+		ErrSessionExpired.Code:              "session-expired",
+		ErrSessionNotFound.Code:             "session-not-found",
+		ErrTokenExpired.Code:                "token-expired",
+		ErrTokenInvalid.Code:                "token-invalid",
+		ErrFamilyNotFound.Code:              "family-not-found",
+		ErrFamilyHasBeenModified.Code:       "family-has-been-modified",
+		ErrFamilyHasMembers.Code:            "family-has-members",
+		ErrFamilyNotOwner.Code:              "family-not-owner",
+		ErrFamilyAddMemberFailed.Code:       "family-add-member-failed",
+		ErrFamilyAlreadyJoined.Code:         "family-already-joined",
+		ErrInvitationNotFound.Code:          "invitation-not-found",
+		ErrInvitationExpired.Code:           "invitation-expired",
+		ErrInviteYourself.Code:              "invite-yourself",
+		ErrInvitationHasBeenHandled.Code:    "invitation-has-been-handled",
+		ErrInvitationCanceled.Code:          "invitation-canceled",
+		ErrMenuAlreadyExists.Code:           "menu-already-exists",
+		ErrMenuNotFound.Code:                "menu-not-found",
+		ErrMenuCategoryNotFound.Code:        "menu-category-not-found",
+		ErrMenuHasBeenModified.Code:         "menu-has-been-modified",
+	},
+}
+
+// SetErrorFormat 设置默认的错误响应格式。
+// 为 ErrorFormatResult 时，Accept 中包含 application/problem+json 的请求仍然返回 problem+json
+func SetErrorFormat(format ErrorFormat) {
+	problems.Lock()
+	defer problems.Unlock()
+	if format != ErrorFormatProblem {
+		format = ErrorFormatResult
+	}
+	problems.format = format
+}
+
+// SetProblemTypeBase 设置 problem type 的 URI 前缀，如 https://a1o.studio/problems/，为空时 type 都为 about:blank
+func SetProblemTypeBase(base string) {
+	problems.Lock()
+	defer problems.Unlock()
+	problems.typeBase = base
+}
+
+// RegisterProblemType 为错误码注册 problem type，slug 拼接在 SetProblemTypeBase 的前缀之后，新增的 AppError 需要在这里注册
+func RegisterProblemType(code int, slug string) {
+	problems.Lock()
+	defer problems.Unlock()
+	problems.types[code] = slug
+}
+
+// ProblemType 返回错误码对应的 type URI，未注册时为 about:blank
+func ProblemType(code int) string {
+	problems.RLock()
+	defer problems.RUnlock()
+	slug, ok := problems.types[code]
+	if !ok || problems.typeBase == "" {
+		return "about:blank"
+	}
+	return problems.typeBase + slug
+}
+
+// NegotiateErrorFormat 根据全局配置和请求的 Accept 选择错误响应的格式，r 为 nil 时只看全局配置
+func NegotiateErrorFormat(r *http.Request) ErrorFormat {
+	problems.RLock()
+	format := problems.format
+	problems.RUnlock()
+	if format == ErrorFormatProblem || r == nil {
+		return format
+	}
+	if acceptsProblem(r.Header.Get("Accept")) {
+		return ErrorFormatProblem
+	}
+	return ErrorFormatResult
+}
+
+// acceptsProblem 判断 Accept 中是否明确列出了 application/problem+json，q=0 表示不接受
+func acceptsProblem(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != ProblemContentType {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// newProblem 将 Result 转换为 Problem，Message 不是字符串时（如参数校验的错误列表）放入 errors 扩展字段
+func newProblem(httpStatus int, res Result[any]) Problem {
+	problem := Problem{
+		Type:     ProblemType(res.Code),
+		Title:    http.StatusText(httpStatus),
+		Status:   httpStatus,
+		Instance: res.RequestID,
+		Code:     res.Code,
+	}
+	switch message := res.Message.(type) {
+	case nil:
+	case string:
+		problem.Detail = message
+	default:
+		problem.Errors = message
+	}
+	return problem
+}
+
+// abortProblem 以 problem+json 写入错误响应，未指定请求 ID 时从 context 中读取
+func abortProblem(c *gin.Context, httpStatus int, res Result[any]) {
+	if res.RequestID == "" {
+		if requestID, ok := logger.RequestIDFromContext(c.Request.Context()); ok {
+			res.RequestID = requestID
+		}
+	}
+	c.Abort()
+	// gin 不会覆盖已经设置的 Content-Type
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(httpStatus, newProblem(httpStatus, res))
+}
+
+func writeProblem(w http.ResponseWriter, httpStatus int, res Result[any]) {
+	body, err := json.Marshal(newProblem(httpStatus, res))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(httpStatus)
+	_, _ = w.Write(body)
+}
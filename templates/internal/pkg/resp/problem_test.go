@@ -0,0 +1,139 @@
+package resp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func setupProblems(t *testing.T, format ErrorFormat) {
+	SetErrorFormat(format)
+	SetProblemTypeBase("https://a1o.studio/problems/")
+	t.Cleanup(func() {
+		SetErrorFormat(ErrorFormatResult)
+		SetProblemTypeBase("")
+	})
+}
+
+func newProblemServer() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	server.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), "req-1"))
+		c.Next()
+	})
+	server.GET("/user", func(c *gin.Context) {
+		HandleErrors(c, ErrUserNotFound, []ErrorMapping{
+			{Target: ErrUserNotFound, Action: func(c *gin.Context, err error) {
+				NotFoundError(c, WithError(ErrUserNotFound))
+			}},
+		})
+	})
+	server.POST("/user", func(c *gin.Context) {
+		FailedValidationError(c, WithMessage([]validation.ValidationError{{Field: "username", Reason: "required"}}))
+	})
+	server.GET("/unregistered", func(c *gin.Context) {
+		Error(c, http.StatusTeapot, 9999, "teapot")
+	})
+	return server
+}
+
+func TestProblem(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   ErrorFormat
+		method   string
+		path     string
+		accept   string
+		wantType string
+		wantBody string
+	}{
+		{
+			name:     "ResultFormat",
+			format:   ErrorFormatResult,
+			method:   http.MethodGet,
+			path:     "/user",
+			accept:   "application/json",
+			wantType: "application/json; charset=utf-8",
+			wantBody: `{"code":1006,"message":"user not found","data":null}`,
+		},
+		{
+			name:     "AcceptProblem",
+			format:   ErrorFormatResult,
+			method:   http.MethodGet,
+			path:     "/user",
+			accept:   "application/problem+json, application/json;q=0.9",
+			wantType: ProblemContentType,
+			wantBody: `{"type":"https://a1o.studio/problems/user-not-found","title":"Not Found","status":404,"detail":"user not found","instance":"req-1","code":1006}`,
+		},
+		{
+			name:     "AcceptProblemQZero",
+			format:   ErrorFormatResult,
+			method:   http.MethodGet,
+			path:     "/user",
+			accept:   "application/problem+json;q=0",
+			wantType: "application/json; charset=utf-8",
+			wantBody: `{"code":1006,"message":"user not found","data":null}`,
+		},
+		{
+			name:     "ProblemFormat",
+			format:   ErrorFormatProblem,
+			method:   http.MethodGet,
+			path:     "/user",
+			wantType: ProblemContentType,
+			wantBody: `{"type":"https://a1o.studio/problems/user-not-found","title":"Not Found","status":404,"detail":"user not found","instance":"req-1","code":1006}`,
+		},
+		{
+			name:     "ValidationErrors",
+			format:   ErrorFormatProblem,
+			method:   http.MethodPost,
+			path:     "/user",
+			wantType: ProblemContentType,
+			wantBody: `{"type":"https://a1o.studio/problems/validation-failed","title":"Unprocessable Entity","status":422,"instance":"req-1","code":422,"errors":[{"field":"username","reason":"required"}]}`,
+		},
+		{
+			name:     "Unregistered",
+			format:   ErrorFormatProblem,
+			method:   http.MethodGet,
+			path:     "/unregistered",
+			wantType: ProblemContentType,
+			wantBody: `{"type":"about:blank","title":"I'm a teapot","status":418,"detail":"teapot","instance":"req-1","code":9999}`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			setupProblems(t, tc.format)
+			server := newProblemServer()
+
+			request := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.accept != "" {
+				request.Header.Set("Accept", tc.accept)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.wantType, recorder.Header().Get("Content-Type"))
+			require.JSONEq(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestWriteErrorProblem(t *testing.T) {
+	setupProblems(t, ErrorFormatResult)
+
+	recorder := httptest.NewRecorder()
+	WriteError(recorder, ErrorFormatProblem, http.StatusGatewayTimeout, ErrGatewayTimeout,
+		WithMessage("request timed out"), WithRequestID("req-2"))
+
+	require.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	require.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"type":"https://a1o.studio/problems/gateway-timeout","title":"Gateway Timeout","status":504,"detail":"request timed out","instance":"req-2","code":504}`, recorder.Body.String())
+}
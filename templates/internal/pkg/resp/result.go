@@ -1,6 +1,7 @@
 package resp
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,14 +11,16 @@ import (
 
 // swagger:model Result
 type Result[T any] struct {
-	Code    int `json:"code" example:"200"` // 业务状态码
-	Message any `json:"message"`            // 消息
-	Data    T   `json:"data"`               // 返回结果
+	Code      int    `json:"code" example:"200"`   // 业务状态码
+	Message   any    `json:"message"`              // 消息
+	Data      T      `json:"data"`                 // 返回结果
+	RequestID string `json:"request_id,omitempty"` // 请求 ID，出错时返回，便于排查日志
 } //	@name	Result
 
 type HttpError struct {
-	Code    int `json:"code"`    // 错误码
-	Message any `json:"message"` // 错误消息
+	Code      int    `json:"code"`                 // 错误码
+	Message   any    `json:"message"`              // 错误消息
+	RequestID string `json:"request_id,omitempty"` // 请求 ID
 } //	@name	HttpError
 
 type ErrorOption func(*Result[any])
@@ -41,6 +44,12 @@ func WithCode(code int) ErrorOption {
 	}
 }
 
+func WithRequestID(requestID string) ErrorOption {
+	return func(r *Result[any]) {
+		r.RequestID = requestID
+	}
+}
+
 func Success[T any](c *gin.Context, data T) {
 	c.JSON(http.StatusOK, Result[T]{
 		Code:    0, // code=0 表示成功
@@ -51,14 +60,45 @@ func Success[T any](c *gin.Context, data T) {
 
 // Error 返回错误响应
 func Error(c *gin.Context, httpStatus, code int, message any) {
-	// Abort 后不会执行后续中间件与 handler 的后续操作
-	c.Abort()
-	c.JSON(httpStatus, Result[any]{
+	abort(c, httpStatus, Result[any]{
 		Code:    code,
 		Message: message,
 	})
 }
 
+func abort(c *gin.Context, httpStatus int, res Result[any]) {
+	if NegotiateErrorFormat(c.Request) == ErrorFormatProblem {
+		abortProblem(c, httpStatus, res)
+		return
+	}
+	// Abort 后不会执行后续中间件与 handler 的后续操作
+	c.Abort()
+	c.JSON(httpStatus, res)
+}
+
+// WriteError 直接向 w 写入错误响应，用于无法安全使用 gin.Context 的场景，例如超时后处理函数仍在运行。
+// format 应在处理函数开始前通过 NegotiateErrorFormat 得到
+func WriteError(w http.ResponseWriter, format ErrorFormat, httpStatus int, err AppError, options ...ErrorOption) {
+	res := Result[any]{
+		Code:    err.Code,
+		Message: err.Message,
+	}
+	updateErrorOption(&res, options...)
+	if format == ErrorFormatProblem {
+		writeProblem(w, httpStatus, res)
+		return
+	}
+
+	body, marshalErr := json.Marshal(res)
+	if marshalErr != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(httpStatus)
+	_, _ = w.Write(body)
+}
+
 // 服务端内部错误 500
 func ServerError(c *gin.Context, options ...ErrorOption) {
 	res := Result[any]{
@@ -66,7 +106,7 @@ func ServerError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrServerError.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusInternalServerError, res.Code, res.Message)
+	abort(c, http.StatusInternalServerError, res)
 }
 
 // 404 错误
@@ -76,7 +116,7 @@ func NotFoundError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrNotFound.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusNotFound, res.Code, res.Message)
+	abort(c, http.StatusNotFound, res)
 }
 
 func WrapNotFoundError(options ...ErrorOption) gin.HandlerFunc {
@@ -92,7 +132,7 @@ func InvalidError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrBadRequest.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusBadRequest, res.Code, res.Message)
+	abort(c, http.StatusBadRequest, res)
 }
 
 // 超时
@@ -102,7 +142,7 @@ func TimeoutError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrGatewayTimeout.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusGatewayTimeout, res.Code, res.Message)
+	abort(c, http.StatusGatewayTimeout, res)
 }
 
 // 参数校验失败
@@ -112,7 +152,7 @@ func FailedValidationError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrStatusUnprocessableEntity.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusUnprocessableEntity, res.Code, res.Message)
+	abort(c, http.StatusUnprocessableEntity, res)
 }
 
 func ForbiddenError(c *gin.Context, options ...ErrorOption) {
@@ -121,7 +161,7 @@ func ForbiddenError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrForbidden.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusForbidden, res.Code, res.Message)
+	abort(c, http.StatusForbidden, res)
 }
 
 func UnauthorizedError(c *gin.Context, options ...ErrorOption) {
@@ -130,7 +170,7 @@ func UnauthorizedError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrUnauthorized.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusUnauthorized, res.Code, res.Message)
+	abort(c, http.StatusUnauthorized, res)
 }
 
 func ConflictError(c *gin.Context, options ...ErrorOption) {
@@ -139,7 +179,47 @@ func ConflictError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrConflict.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusConflict, res.Code, res.Message)
+	abort(c, http.StatusConflict, res)
+}
+
+// 前置条件不满足 412，资源已被修改
+func PreconditionFailedError(c *gin.Context, options ...ErrorOption) {
+	res := Result[any]{
+		Code:    ErrPreconditionFailed.Code,
+		Message: ErrPreconditionFailed.Message,
+	}
+	updateErrorOption(&res, options...)
+	abort(c, http.StatusPreconditionFailed, res)
+}
+
+// AsPreconditionFailed 用于 ErrorMapping.Action，将 ErrUserHasBeenModified 等乐观锁冲突以 412 返回，保留原来的错误码
+func AsPreconditionFailed(c *gin.Context, err error) {
+	var appErr AppError
+	if errors.As(err, &appErr) {
+		PreconditionFailedError(c, WithError(appErr))
+		return
+	}
+	PreconditionFailedError(c)
+}
+
+// 请求体过大 413
+func RequestEntityTooLargeError(c *gin.Context, options ...ErrorOption) {
+	res := Result[any]{
+		Code:    ErrRequestEntityTooLarge.Code,
+		Message: ErrRequestEntityTooLarge.Message,
+	}
+	updateErrorOption(&res, options...)
+	abort(c, http.StatusRequestEntityTooLarge, res)
+}
+
+// 不支持的请求体编码 415
+func UnsupportedMediaTypeError(c *gin.Context, options ...ErrorOption) {
+	res := Result[any]{
+		Code:    ErrUnsupportedMediaType.Code,
+		Message: ErrUnsupportedMediaType.Message,
+	}
+	updateErrorOption(&res, options...)
+	abort(c, http.StatusUnsupportedMediaType, res)
 }
 
 // 不支持的请求方法
@@ -149,7 +229,7 @@ func MethodNotAllowedError(c *gin.Context, options ...ErrorOption) {
 		Code:    ErrMethodNotAllowed.Code,
 		Message: message,
 	}
-	Error(c, http.StatusMethodNotAllowed, res.Code, res.Message)
+	abort(c, http.StatusMethodNotAllowed, res)
 }
 
 func TooManyRequestsError(c *gin.Context, options ...ErrorOption) {
@@ -158,7 +238,7 @@ func TooManyRequestsError(c *gin.Context, options ...ErrorOption) {
 		Message: ErrTooManyRequests.Message,
 	}
 	updateErrorOption(&res, options...)
-	Error(c, http.StatusTooManyRequests, res.Code, res.Message)
+	abort(c, http.StatusTooManyRequests, res)
 }
 
 func WrapMethodNotAllowedError(options ...ErrorOption) gin.HandlerFunc {
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/a1ostudio/nova"
+
+type Options struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	Endpoint       string                // OTLP/HTTP 地址，如 http://localhost:4318，为空且未指定 Exporter 时不导出 span
+	SampleRatio    *float64              // 采样率，nil 时为 1，0 表示只采样上游已采样的请求
+	Exporter       sdktrace.SpanExporter // 自定义导出器，测试时可以传入 tracetest.InMemoryExporter
+}
+
+// Setup 设置全局 TracerProvider 和 W3C traceparent/baggage 传播，返回的函数在关闭时刷新未导出的 span。
+// 没有配置导出器时仍然会传播上游的 trace context，日志中的 trace_id 可以和上下游关联
+func Setup(ctx context.Context, opts Options) (shutdown func(ctx context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter := opts.Exporter
+	if exporter == nil && opts.Endpoint != "" {
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceName(opts.ServiceName)}
+	if opts.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(opts.ServiceVersion))
+	}
+	if opts.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment.name", opts.Environment))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+	if err != nil && !errors.Is(err, resource.ErrSchemaURLConflict) {
+		return nil, err
+	}
+
+	ratio := 1.0
+	if opts.SampleRatio != nil {
+		ratio = *opts.SampleRatio
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}
+	if exporter != nil {
+		if opts.Exporter != nil {
+			// 测试时同步导出，span 结束后即可读取
+			providerOpts = append(providerOpts, sdktrace.WithSyncer(exporter))
+		} else {
+			providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer 返回应用使用的 tracer，总是从全局 provider 获取，Setup 之前得到的是 noop tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Inject 将 ctx 中的 trace context 写入 carrier，用于跨进程传递（如后台任务的 metadata）
+func Inject(ctx context.Context, carrier map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+// Extract 从 carrier 中恢复 trace context
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// EndSpan 记录错误并结束 span
+func EndSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupTestTracing(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown, err := Setup(context.Background(), Options{ServiceName: "test", Exporter: exporter})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+	return exporter
+}
+
+func TestRedisHook(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	rdb.AddHook(RedisHook{})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	require.NoError(t, rdb.Set(ctx, "key", "value", 0).Err())
+	require.ErrorIs(t, rdb.Get(ctx, "missing").Err(), redis.Nil)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name)
+		if span.Name == "set" || span.Name == "get" {
+			require.Equal(t, trace.SpanKindClient, span.SpanKind)
+			require.Equal(t, parent.SpanContext().TraceID(), span.SpanContext.TraceID())
+			require.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+			// key 不存在不是错误
+			require.Empty(t, span.Events)
+		}
+	}
+	require.Contains(t, names, "set")
+	require.Contains(t, names, "get")
+}
+
+func TestSampleRatio(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	ratio := 0.0
+	shutdown, err := Setup(context.Background(), Options{ServiceName: "test", Exporter: exporter, SampleRatio: &ratio})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+
+	_, span := Tracer().Start(context.Background(), "unsampled")
+	span.End()
+	require.Empty(t, exporter.GetSpans())
+
+	// 上游已采样的请求仍然采样
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, span = Tracer().Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "sampled")
+	span.End()
+	require.Len(t, exporter.GetSpans(), 1)
+}
+
+func TestQueryName(t *testing.T) {
+	testCases := []struct {
+		sql  string
+		want string
+	}{
+		{sql: "-- name: GetSession :one\nSELECT * FROM sessions WHERE id = $1", want: "GetSession"},
+		{sql: "  select 1", want: "SELECT"},
+		{sql: "", want: "query"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, queryName(tc.sql))
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	setupTestTracing(t)
+
+	ctx, span := Tracer().Start(context.Background(), "producer")
+	defer span.End()
+
+	carrier := map[string]string{}
+	Inject(ctx, carrier)
+	require.Contains(t, carrier, "traceparent")
+
+	extracted := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+	require.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	require.True(t, extracted.IsRemote())
+}
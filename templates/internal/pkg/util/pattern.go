@@ -0,0 +1,4 @@
+package util
+
+// PhonePattern 中国大陆手机号，不含首尾锚点，校验和日志脱敏共用
+const PhonePattern = `1(?:3\d|4[5-9]|5[0-35-9]|6[2567]|7[0-8]|8\d|9[0-35-9])\d{8}`
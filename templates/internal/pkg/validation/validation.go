@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/util"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
@@ -37,6 +38,8 @@ func NewValidation() {
 	}
 }
 
+var phoneRegexp = regexp.MustCompile("^" + util.PhonePattern + "$")
+
 func validatePhone(fl validator.FieldLevel) bool {
 	phone := fl.Field().String()
 	if len(phone) != 11 {
@@ -44,10 +47,7 @@ func validatePhone(fl validator.FieldLevel) bool {
 	}
 
 	// 正则匹配手机
-	pattern := `^1(?:3\d|4[5-9]|5[0-35-9]|6[2567]|7[0-8]|8\d|9[0-35-9])\d{8}$`
-	re := regexp.MustCompile(pattern)
-
-	return re.MatchString(phone)
+	return phoneRegexp.MatchString(phone)
 }
 
 func validatePassword(fl validator.FieldLevel) bool {
@@ -1,29 +1,131 @@
 package server
 
 import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/a1ostudio/nova/internal/asyncq"
+	"github.com/a1ostudio/nova/internal/controller"
+	"github.com/a1ostudio/nova/internal/logger"
 	"github.com/a1ostudio/nova/internal/model"
+	"github.com/a1ostudio/nova/internal/pkg/buildinfo"
+	"github.com/a1ostudio/nova/internal/pkg/health"
 	"github.com/a1ostudio/nova/internal/pkg/resp"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-const version = "1.0.0"
+// readyzCacheTTL /readyz 是公开接口且不经过限流，检查结果在这段时间内复用
+const readyzCacheTTL = time.Second
 
-// Healthcheck
+const (
+	statusAvailable    = "available"
+	statusDegraded     = "degraded"
+	statusShuttingDown = "shutting_down"
+)
+
+// registerHealthChecks 注册就绪检查项，controller 实现 controller.HealthChecker 时也会注册它的检查项
+func (server *Server) registerHealthChecks() {
+	server.health.Register("database", server.store.Ping, 0)
+	server.health.Register("redis", func(ctx context.Context) error {
+		return server.redis.Ping(ctx).Err()
+	}, 0)
+	server.health.Register("queue", asyncq.HealthCheck(server.redis, nil, 0), 0)
+
+	for _, ctrl := range server.controllers {
+		if checker, ok := ctrl.(controller.HealthChecker); ok {
+			checker.RegisterHealthChecks(server.health)
+		}
+	}
+}
+
+func (server *Server) system() model.System {
+	return model.System{
+		Environment: server.config.Env,
+		Version:     buildinfo.Version,
+	}
+}
+
+// Livez
 //
-//	@Summary		状态检测
-//	@Description	检测当前 API 服务状态
+//	@Summary		存活检测
+//	@Description	进程能够处理请求即返回 200，不检查外部依赖
 //	@Tags			Common
 //	@Success		200	{object}	resp.Result[model.Healthcheck]	"返回状态信息"
-//	@Router			/v1/healthcheck [get]
-func (server Server) healthcheck(ctx *gin.Context) {
+//	@Router			/livez [get]
+func (server *Server) livez(ctx *gin.Context) {
+	resp.Success(ctx, &model.Healthcheck{
+		Status: statusAvailable,
+		System: server.system(),
+	})
+}
+
+// Readyz
+//
+//	@Summary		就绪检测
+//	@Description	检查数据库、Redis、任务队列等依赖，任一依赖不可用或服务正在关闭时返回 503。结果缓存 1 秒，只返回各依赖的状态
+//	@Tags			Common
+//	@Success		200	{object}	resp.Result[model.Healthcheck]	"所有依赖可用"
+//	@Failure		503	{object}	resp.Result[model.Healthcheck]	"部分依赖不可用"
+//	@Router			/readyz [get]
+func (server *Server) readyz(ctx *gin.Context) {
+	if server.draining.Load() {
+		serviceUnavailable(ctx, &model.Healthcheck{
+			Status: statusShuttingDown,
+			System: server.system(),
+		})
+		return
+	}
+
+	report := server.health.CheckCached(ctx.Request.Context(), readyzCacheTTL)
+
 	data := &model.Healthcheck{
-		Status: "available",
-		System: model.System{
-			Environment: server.config.Env,
-			Version:     version,
-		},
+		Status:     statusAvailable,
+		System:     server.system(),
+		Components: make(map[string]model.ComponentHealth, len(report.Components)),
+	}
+	for name, result := range report.Components {
+		// 错误信息可能包含主机和端口，只写入日志
+		data.Components[name] = model.ComponentHealth{
+			Status:    string(result.Status),
+			LatencyMs: float64(result.Latency) / float64(time.Millisecond),
+		}
+		if result.Status != health.StatusUp {
+			logger.FromContext(ctx.Request.Context()).Warn("readiness check failed",
+				zap.String("component", name),
+				zap.Duration("latency", result.Latency),
+				zap.String("error", result.Error),
+			)
+		}
 	}
 
+	if report.Status != health.StatusUp {
+		data.Status = statusDegraded
+		serviceUnavailable(ctx, data)
+		return
+	}
 	resp.Success(ctx, data)
 }
+
+// serviceUnavailable 以 503 返回检查结果，负载均衡只看状态码，响应体供排查使用
+func serviceUnavailable(ctx *gin.Context, data *model.Healthcheck) {
+	ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, resp.Result[*model.Healthcheck]{
+		Code:    resp.ErrServiceUnavailable.Code,
+		Message: resp.ErrServiceUnavailable.Message,
+		Data:    data,
+	})
+}
+
+// Healthcheck
+//
+//	@Summary		状态检测
+//	@Description	检测当前 API 服务状态，与 /readyz 相同
+//	@Tags			Common
+//	@Success		200	{object}	resp.Result[model.Healthcheck]	"返回状态信息"
+//	@Failure		503	{object}	resp.Result[model.Healthcheck]	"部分依赖不可用"
+//	@Router			/v1/healthcheck [get]
+func (server *Server) healthcheck(ctx *gin.Context) {
+	server.readyz(ctx)
+}
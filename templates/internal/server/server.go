@@ -5,10 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -17,13 +17,16 @@ import (
 	"github.com/a1ostudio/nova/internal/controller"
 	"github.com/a1ostudio/nova/internal/logger"
 	"github.com/a1ostudio/nova/internal/middleware"
+	"github.com/a1ostudio/nova/internal/pkg/health"
+	"github.com/a1ostudio/nova/internal/pkg/metrics"
+	"github.com/a1ostudio/nova/internal/pkg/recovery"
 	"github.com/a1ostudio/nova/internal/pkg/resp"
 	"github.com/a1ostudio/nova/internal/pkg/token"
 	"github.com/a1ostudio/nova/internal/pkg/validation"
+	"github.com/a1ostudio/nova/internal/service"
 
 	docs "github.com/a1ostudio/nova/docs"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
@@ -38,8 +41,12 @@ type Server struct {
 	tokenMaker  token.Maker
 	router      *gin.Engine
 	redis       *redis.Client
+	rateLimiter middleware.RateLimiter // 限流器，controller 可以用它为路由组挂载更严格的策略
 	controllers []controller.RegisterRoutes
-	httpServer  *http.Server // 保存 HTTP 服务器引用
+	health      *health.Registry // 就绪检查项
+	draining    atomic.Bool      // 正在关闭，就绪检查直接返回失败
+	httpServer  *http.Server     // 保存 HTTP 服务器引用
+	metricsHTTP *http.Server     // 内部端口上的 /metrics
 }
 
 func NewServer(config config.Config, store db.Store, redis *redis.Client) (*Server, error) {
@@ -48,19 +55,38 @@ func NewServer(config config.Config, store db.Store, redis *redis.Client) (*Serv
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	// 所有实例通过 Redis 共享限流配额，Redis 不可用时降级为进程内限流
+	fallbackLimiter := middleware.NewMemoryRateLimiter()
+	recovery.Go(context.Background(), "ratelimit.cleanup", func() {
+		fallbackLimiter.Cleanup(1*time.Minute, 5*time.Minute)
+	})
+	rateLimiter := middleware.NewRedisRateLimiter(redis, fallbackLimiter)
+
 	// Register controllers
+	// 需要分布式锁的 service 使用 redislock.NewLocker(redis, redislock.Options{TTL: config.LockTTL, ...}) 创建，
+	// 需要投递后台任务的 service 使用 asyncq.NewRedisTaskDistributor(redis)，或在事务中写入 outbox
+	sessionService := service.NewSessionService(config, store, tokenMaker)
 
 	server := &Server{
 		config:      config,
 		store:       store,
 		tokenMaker:  tokenMaker,
 		redis:       redis,
-		controllers: []controller.RegisterRoutes{},
+		rateLimiter: rateLimiter,
+		controllers: []controller.RegisterRoutes{
+			controller.NewTokenController(sessionService, rateLimiter),
+			controller.NewAdminController(config, tokenMaker),
+		},
+		health: health.NewRegistry(),
 	}
+	server.registerHealthChecks()
 
 	// 注册 validation
 	validation.NewValidation()
 
+	resp.SetErrorFormat(resp.ErrorFormat(config.ErrorFormat))
+	resp.SetProblemTypeBase(server.problemTypeBase())
+
 	server.setupRouter()
 	return server, nil
 }
@@ -73,37 +99,43 @@ func (server *Server) setupRouter() {
 	router.HandleMethodNotAllowed = true
 	router.NoMethod(resp.WrapMethodNotAllowedError())
 
-	// CORS middleware
-	cors := cors.New(cors.Config{
-		AllowOriginFunc: func(origin string) bool { // 允许的前端地址
-			u, err := url.Parse(origin)
-			if err != nil {
-				return false
-			}
-			host := u.Hostname() // 只取主机名，不含端口
-			return strings.HasSuffix(host, server.config.Domain)
-		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}, // 允许的方法
-		AllowHeaders:     []string{"Origin", "Content-Type"},                  // 允许的请求头
-		ExposeHeaders:    []string{"Content-Length"},                          // 允许前端获取的响应头
-		AllowCredentials: true,                                                // 允许携带 Cookie
-		MaxAge:           12 * time.Hour,
-	})
+	// 探针在中间件之前注册，不经过日志、限流和超时中间件
+	router.GET("/livez", server.livez)
+	router.GET("/readyz", server.readyz)
+
+	router.MaxMultipartMemory = 8 << 20 // multipart 表单最多在内存中缓存 8MB，其余写入临时文件，上传大小由 BodyLimit 限制
+	// CORS，未匹配任何路由的预检请求也要经过它，所以挂在全局
+	router.Use(middleware.CORS(server.corsPolicy(), server.corsRules()...))
 
-	router.MaxMultipartMemory = 8 << 20 // 8MB 限制上传文件大小
-	router.Use(cors)
+	router.Use(middleware.SecureHeaders(server.secureHeaders()))
 
-	// middlewares
+	// middlewares，Tracing 需要在 LoggerMiddleware 之前，日志中才有 trace_id
+	router.Use(middleware.Tracing())
 	router.Use(logger.LoggerMiddleware())
-	router.Use(middleware.RecoverPanic())
+	router.Use(middleware.Metrics())
+	router.Use(middleware.RecoverPanic(server.config.Env == config.Dev))
+
+	// Rate limiting middleware，全局按 IP 限流，路由组可以再挂载更严格的策略
+	router.Use(middleware.RateLimit(server.rateLimiter, middleware.RateLimitPolicy{
+		Name:  "global",
+		Limit: middleware.PerSecond(server.config.LimitRate, server.config.LimitBurst),
+		Key:   middleware.KeyByIP,
+	}))
+
+	// 请求体大小限制，文件上传等路由在 Routes 中按路由模板单独设置
+	router.Use(middleware.BodyLimit(middleware.BodyLimitOptions{
+		Limit:           server.config.MaxBodyBytes,
+		MaxDecompressed: server.config.MaxDecompressedBytes,
+	}))
 
-	go middleware.CleanupClients(1*time.Minute, 5*time.Minute)
-	// Rate limiting middleware
-	router.Use(middleware.RateLimitByIPMiddleware(server.config.LimitRate, server.config.LimitBurst))
+	// 响应压缩，需要在 Timeout 之前，超时的 504 和缓冲后提交的响应都经过压缩。SSE 等流式路由加入 Exempt
+	router.Use(middleware.Compress(middleware.CompressOptions{}))
 
 	if server.config.Env != config.Dev {
-		// 5s 超时
-		router.Use(middleware.Timeout(5 * time.Second))
+		// 默认 5s 超时，Routes 可以按路由模板单独设置，文件上传、SSE 等长连接的路由加入 Exempt
+		router.Use(middleware.TimeoutWithOptions(middleware.TimeoutOptions{
+			Timeout: 5 * time.Second,
+		}))
 	}
 
 	docs.SwaggerInfo.Version = "v1.0.0"
@@ -120,13 +152,60 @@ func (server *Server) setupRouter() {
 		}
 
 		if server.config.Env != config.Prod {
-			router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+			// Swagger UI 使用内联脚本和样式，单独放宽 CSP
+			swaggerHeaders := server.secureHeaders()
+			swaggerHeaders.ContentSecurityPolicy = middleware.SwaggerCSP
+			router.GET("/swagger/*any", middleware.SecureHeaders(swaggerHeaders), ginSwagger.WrapHandler(swaggerFiles.Handler))
 		}
 	}
 
 	server.router = router
 }
 
+// problemTypeBase problem type 的 URI 前缀，未配置时使用 SERVER_DOMAIN
+func (server *Server) problemTypeBase() string {
+	if server.config.ProblemTypeBaseURL != "" {
+		return server.config.ProblemTypeBaseURL
+	}
+	return "https://" + strings.TrimPrefix(server.config.Domain, ".") + "/problems/"
+}
+
+// corsPolicy 全局跨域策略，未配置 CORS_ALLOW_ORIGINS 时允许 SERVER_DOMAIN 及其子域名
+func (server *Server) corsPolicy() middleware.CORSPolicy {
+	origins := server.config.CORSAllowOrigins
+	if len(origins) == 0 {
+		origins = middleware.DomainOrigins(server.config.Domain)
+	}
+	return middleware.CORSPolicy{
+		AllowOrigins:     origins,
+		AllowMethods:     server.config.CORSAllowMethods,
+		AllowHeaders:     server.config.CORSAllowHeaders,
+		ExposeHeaders:    server.config.CORSExposeHeaders,
+		AllowCredentials: server.config.CORSAllowCredentials,
+		MaxAge:           server.config.CORSMaxAge,
+	}
+}
+
+// secureHeaders 按环境选择安全响应头，配置了 CONTENT_SECURITY_POLICY 时覆盖默认的 CSP
+func (server *Server) secureHeaders() middleware.SecureHeadersOptions {
+	opts := middleware.SecureHeadersPreset(server.config.Env)
+	if server.config.ContentSecurityPolicy != "" {
+		opts.ContentSecurityPolicy = server.config.ContentSecurityPolicy
+	}
+	return opts
+}
+
+// corsRules 路由组单独的跨域策略，配置了 CORS_ADMIN_ALLOW_ORIGINS 时管理接口只允许这些 origin
+func (server *Server) corsRules() []middleware.CORSRule {
+	var rules []middleware.CORSRule
+	if len(server.config.CORSAdminAllowOrigins) > 0 {
+		policy := server.corsPolicy()
+		policy.AllowOrigins = server.config.CORSAdminAllowOrigins
+		rules = append(rules, middleware.CORSRule{PathPrefix: "/nova/v1/admin", Policy: policy})
+	}
+	return rules
+}
+
 func (server *Server) Start() error {
 	addr := fmt.Sprintf(":%d", server.config.Port)
 	server.httpServer = &http.Server{
@@ -134,6 +213,8 @@ func (server *Server) Start() error {
 		Handler: server.router,
 	}
 
+	server.startMetrics()
+
 	logger.L().Info("Starting server...", zap.String("addr", addr))
 
 	err := server.httpServer.ListenAndServe()
@@ -143,14 +224,47 @@ func (server *Server) Start() error {
 	return nil
 }
 
-// Shutdown 优雅关闭 HTTP 服务器
+// startMetrics 在独立的内部端口上暴露 Prometheus 指标，避免通过公网入口访问
+func (server *Server) startMetrics() {
+	if server.config.MetricsPort <= 0 {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	addr := fmt.Sprintf(":%d", server.config.MetricsPort)
+	server.metricsHTTP = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	logger.L().Info("Starting metrics server...", zap.String("addr", addr))
+	recovery.Go(context.Background(), "metrics.server", func() {
+		if err := server.metricsHTTP.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.L().Error("metrics server failed", zap.Error(err))
+		}
+	})
+}
+
+// MarkUnready 让就绪检查返回失败，负载均衡据此停止转发新请求，已有连接仍正常处理
+func (server *Server) MarkUnready() {
+	server.draining.Store(true)
+}
+
+// Shutdown 停止接受新连接，并等待处理中的请求完成
 func (server *Server) Shutdown(ctx context.Context) error {
 	if server.httpServer == nil {
 		return nil
 	}
 
 	logger.L().Info("Shutting down HTTP server...")
-	return server.httpServer.Shutdown(ctx)
+	err := server.httpServer.Shutdown(ctx)
+	if server.metricsHTTP != nil {
+		err = errors.Join(err, server.metricsHTTP.Shutdown(ctx))
+	}
+	return err
 }
 
 // Deprecated: 请使用 Start 和 Shutdown 方法组合替代本方法。
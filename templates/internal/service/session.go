@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/a1ostudio/nova/db/sqlc"
+	"github.com/a1ostudio/nova/internal/config"
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/crypto"
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+	"github.com/a1ostudio/nova/internal/pkg/token"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// SessionMeta 记录创建会话时的客户端信息
+type SessionMeta struct {
+	UserAgent string
+	ClientIP  string
+}
+
+// TokenPair 一次登录或续期签发的令牌
+type TokenPair struct {
+	AccessToken    string
+	AccessPayload  *token.Payload
+	RefreshToken   string
+	RefreshPayload *token.Payload
+}
+
+type SessionService struct {
+	config     config.Config
+	store      db.Store
+	tokenMaker token.Maker
+}
+
+func NewSessionService(config config.Config, store db.Store, tokenMaker token.Maker) *SessionService {
+	return &SessionService{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+	}
+}
+
+// CreateSession 为登录用户创建新的会话族，并签发 access token 与 refresh token
+func (service *SessionService) CreateSession(ctx context.Context, userID int64, isStaff int16, meta SessionMeta) (TokenPair, error) {
+	pair, arg, err := service.issueTokens(uuid.New(), userID, isStaff, meta, service.config.RefreshTokenDuration)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if _, err := service.store.CreateSession(ctx, arg); err != nil {
+		return TokenPair{}, fmt.Errorf("create session: %w", err)
+	}
+	return pair, nil
+}
+
+// RenewAccessToken 使用 refresh token 换取新的令牌，每次使用都会轮换 refresh token。
+// 已被轮换过的 refresh token 再次出现时视为泄露，整个会话族都会被阻止。
+// 轮换后的会话不会晚于当前会话过期，因此整个会话族在登录后 RefreshTokenDuration 内必须重新登录
+func (service *SessionService) RenewAccessToken(ctx context.Context, refreshToken string, meta SessionMeta) (TokenPair, error) {
+	payload, err := service.tokenMaker.VerifyToken(refreshToken, token.TokenTypeRefresh)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	session, err := service.store.GetSession(ctx, payload.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TokenPair{}, resp.ErrSessionNotFound
+		}
+		return TokenPair{}, fmt.Errorf("get session: %w", err)
+	}
+
+	if session.IsBlocked {
+		return TokenPair{}, resp.ErrSessionBlocked
+	}
+
+	if session.UserID != payload.UserID {
+		return TokenPair{}, resp.ErrSessionUserIDMismatch
+	}
+
+	if session.RefreshTokenHash != crypto.Sum256([]byte(refreshToken)) {
+		return TokenPair{}, resp.ErrTokenInvalid
+	}
+
+	if session.ReplacedBy.Valid {
+		return TokenPair{}, service.blockFamily(ctx, session)
+	}
+
+	remaining := time.Until(session.ExpiresAt)
+	if remaining <= 0 {
+		return TokenPair{}, resp.ErrSessionExpired
+	}
+
+	pair, arg, err := service.issueTokens(session.FamilyID, session.UserID, payload.IsStaff, meta, min(service.config.RefreshTokenDuration, remaining))
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	_, err = service.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID:  session.ID,
+		NewSession: arg,
+	})
+	if errors.Is(err, db.ErrSessionAlreadyRotated) {
+		return TokenPair{}, service.blockFamily(ctx, session)
+	}
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("rotate session: %w", err)
+	}
+
+	return pair, nil
+}
+
+// issueTokens 签发令牌并返回 refresh token 对应的会话，会话 ID 和过期时间与 refresh token 的 payload 一致
+func (service *SessionService) issueTokens(familyID uuid.UUID, userID int64, isStaff int16, meta SessionMeta, refreshDuration time.Duration) (TokenPair, db.CreateSessionParams, error) {
+	accessToken, accessPayload, err := service.tokenMaker.CreateToken(userID, isStaff, service.config.AccessTokenDuration, token.TokenTypeAccess)
+	if err != nil {
+		return TokenPair{}, db.CreateSessionParams{}, fmt.Errorf("create access token: %w", err)
+	}
+
+	refreshToken, refreshPayload, err := service.tokenMaker.CreateToken(userID, isStaff, refreshDuration, token.TokenTypeRefresh)
+	if err != nil {
+		return TokenPair{}, db.CreateSessionParams{}, fmt.Errorf("create refresh token: %w", err)
+	}
+
+	pair := TokenPair{
+		AccessToken:    accessToken,
+		AccessPayload:  accessPayload,
+		RefreshToken:   refreshToken,
+		RefreshPayload: refreshPayload,
+	}
+	return pair, db.CreateSessionParams{
+		ID:               refreshPayload.ID,
+		FamilyID:         familyID,
+		UserID:           userID,
+		RefreshTokenHash: crypto.Sum256([]byte(refreshToken)),
+		UserAgent:        meta.UserAgent,
+		ClientIp:         meta.ClientIP,
+		ExpiresAt:        refreshPayload.ExpiredAt,
+	}, nil
+}
+
+// blockFamily 检测到 refresh token 被重复使用时阻止整个会话族
+func (service *SessionService) blockFamily(ctx context.Context, session db.Session) error {
+	logger.FromContext(ctx).Warn("refresh token reuse detected, blocking session family",
+		zap.String("session_id", session.ID.String()),
+		zap.String("family_id", session.FamilyID.String()),
+		zap.Int64("user_id", session.UserID),
+	)
+
+	if err := service.store.BlockSessionFamily(ctx, session.FamilyID); err != nil {
+		return fmt.Errorf("block session family: %w", err)
+	}
+	return resp.ErrSessionBlocked
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	mockdb "github.com/a1ostudio/nova/db/mock"
+	db "github.com/a1ostudio/nova/db/sqlc"
+	"github.com/a1ostudio/nova/internal/config"
+	"github.com/a1ostudio/nova/internal/logger"
+	"github.com/a1ostudio/nova/internal/pkg/crypto"
+	"github.com/a1ostudio/nova/internal/pkg/resp"
+	"github.com/a1ostudio/nova/internal/pkg/token"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMain(m *testing.M) {
+	logger.NewLogger(config.Config{Env: config.Dev})
+
+	os.Exit(m.Run())
+}
+
+func newTestSessionService(t *testing.T, store db.Store) (*SessionService, token.Maker) {
+	tokenMaker, err := token.NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+	return NewSessionService(config.Config{
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}, store, tokenMaker), tokenMaker
+}
+
+// newTestSession 签发 refresh token 并返回与之对应的会话
+func newTestSession(t *testing.T, tokenMaker token.Maker, expiresIn time.Duration) (string, db.Session) {
+	refreshToken, payload, err := tokenMaker.CreateToken(1, 0, time.Hour, token.TokenTypeRefresh)
+	require.NoError(t, err)
+	return refreshToken, db.Session{
+		ID:               payload.ID,
+		FamilyID:         uuid.New(),
+		UserID:           1,
+		RefreshTokenHash: crypto.Sum256([]byte(refreshToken)),
+		ExpiresAt:        time.Now().Add(expiresIn),
+	}
+}
+
+func TestRenewAccessToken(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expiresIn  time.Duration
+		replaced   bool
+		buildStubs func(store *mockdb.MockStore, session db.Session)
+		check      func(t *testing.T, session db.Session, pair TokenPair, err error)
+	}{
+		{
+			name:      "Rotate",
+			expiresIn: 30 * time.Minute,
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, arg db.RotateSessionTxParams) (db.Session, error) {
+						require.Equal(t, session.ID, arg.SessionID)
+						require.Equal(t, session.FamilyID, arg.NewSession.FamilyID)
+						require.Equal(t, session.UserID, arg.NewSession.UserID)
+						return db.Session{ID: arg.NewSession.ID, FamilyID: arg.NewSession.FamilyID}, nil
+					})
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, session db.Session, pair TokenPair, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, pair.AccessToken)
+				require.NotEqual(t, session.ID, pair.RefreshPayload.ID)
+				// 轮换不会延长会话族的有效期
+				require.WithinDuration(t, session.ExpiresAt, pair.RefreshPayload.ExpiredAt, time.Second)
+			},
+		},
+		{
+			name:      "ReuseRotatedToken",
+			expiresIn: 30 * time.Minute,
+			replaced:  true,
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), session.FamilyID).Return(nil)
+			},
+			check: func(t *testing.T, session db.Session, pair TokenPair, err error) {
+				require.ErrorIs(t, err, resp.ErrSessionBlocked)
+				require.Empty(t, pair.RefreshToken)
+			},
+		},
+		{
+			name:      "ConcurrentRotation",
+			expiresIn: 30 * time.Minute,
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				// 另一个请求在读取会话之后抢先完成了轮换，RotateSession 影响 0 行
+				store.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Return(db.Session{}, db.ErrSessionAlreadyRotated)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), session.FamilyID).Return(nil)
+			},
+			check: func(t *testing.T, session db.Session, pair TokenPair, err error) {
+				require.ErrorIs(t, err, resp.ErrSessionBlocked)
+				require.Empty(t, pair.RefreshToken)
+			},
+		},
+		{
+			name:      "SessionExpired",
+			expiresIn: -time.Minute,
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, session db.Session, pair TokenPair, err error) {
+				require.ErrorIs(t, err, resp.ErrSessionExpired)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			service, tokenMaker := newTestSessionService(t, store)
+
+			refreshToken, session := newTestSession(t, tokenMaker, tc.expiresIn)
+			if tc.replaced {
+				session.ReplacedBy = pgtype.UUID{Bytes: uuid.New(), Valid: true}
+			}
+			tc.buildStubs(store, session)
+
+			pair, err := service.RenewAccessToken(context.Background(), refreshToken, SessionMeta{})
+			tc.check(t, session, pair, err)
+		})
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	service, _ := newTestSessionService(t, store)
+
+	var arg db.CreateSessionParams
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, params db.CreateSessionParams) (db.Session, error) {
+			arg = params
+			return db.Session{ID: params.ID}, nil
+		})
+
+	pair, err := service.CreateSession(context.Background(), 1, 0, SessionMeta{UserAgent: "test", ClientIP: "127.0.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, pair.RefreshPayload.ID, arg.ID)
+	// 会话的过期时间与 refresh token 的 exp 一致
+	require.Equal(t, pair.RefreshPayload.ExpiredAt, arg.ExpiresAt)
+	require.Equal(t, crypto.Sum256([]byte(pair.RefreshToken)), arg.RefreshTokenHash)
+	require.Equal(t, "test", arg.UserAgent)
+}